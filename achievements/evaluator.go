@@ -0,0 +1,33 @@
+package achievements
+
+import (
+	"game-mining-server/app"
+	"game-mining-server/configs"
+	"game-mining-server/notifications"
+	"log"
+)
+
+// Emit evaluate achievements of user after an event of checkin, task, moment, invite or points flows in background,
+// newly awarded badges are notified by bot. Points rewarded with badges may lift user's rank, so rank is evaluated again
+func Emit(uid int64, event string) {
+	if uid == 0 {
+		return
+	}
+	go func() {
+		awarded, e0 := app.DB().AchievementEvaluate(uid, event, app.Config())
+		if e0 != nil {
+			log.Printf("Evaluate achievements of user %d on %s failed: %s\n", uid, event, e0)
+			return
+		}
+		rewarded := false
+		for _, achievement := range awarded {
+			rewarded = rewarded || achievement.RewardPoint > 0
+			if e1 := notifications.NotifyAchievement(achievement); e1 != nil {
+				log.Printf("Notify achievement %s of user %d failed: %s\n", achievement.AchievementId, uid, e1)
+			}
+		}
+		if rewarded && event != configs.AchievementEventPoints {
+			Emit(uid, configs.AchievementEventPoints)
+		}
+	}()
+}
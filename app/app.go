@@ -5,6 +5,9 @@ import (
 	"game-mining-server/caches"
 	"game-mining-server/configs"
 	"game-mining-server/dbs"
+	"game-mining-server/prices"
+	"game-mining-server/proxies"
+	"game-mining-server/rpcs"
 	"github.com/mymmrac/telego"
 )
 
@@ -13,6 +16,9 @@ type App struct {
 	Bot    *telego.Bot
 	DB     *dbs.Service
 	Cache  *caches.Service
+	Proxy  *proxies.Service
+	Rpc    *rpcs.Service
+	Price  *prices.Service
 }
 
 var instance App
@@ -24,12 +30,31 @@ func CreateApp(cfgPath string, botToken string) error {
 		return e0
 	}
 
+	proxy, e1 := proxies.CreateProxyService(cfg.Proxy)
+	if e1 != nil {
+		return e1
+	}
+
 	db := dbs.CreateDBService(cfg.Database, cfg.Basic.Env)
+	cache := caches.CreateCacheService(cfg.Cache)
+	rpc, e2 := rpcs.CreateRpcService(cfg.Rpc, cache)
+	if e2 != nil {
+		return e2
+	}
+
+	price, e3 := prices.CreatePriceService(cfg.Price, cache)
+	if e3 != nil {
+		return e3
+	}
+
 	instance = App{
 		Config: cfg,
 		Bot:    bot,
 		DB:     db,
-		Cache:  caches.CreateCacheService(cfg.Cache),
+		Cache:  cache,
+		Proxy:  proxy,
+		Rpc:    rpc,
+		Price:  price,
 	}
 	return nil
 }
@@ -61,3 +86,15 @@ func DB() *dbs.Service {
 func Bot() *telego.Bot {
 	return instance.Bot
 }
+
+func Proxy() *proxies.Service {
+	return instance.Proxy
+}
+
+func Rpc() *rpcs.Service {
+	return instance.Rpc
+}
+
+func Price() *prices.Service {
+	return instance.Price
+}
@@ -1,12 +1,61 @@
 package caches
 
-import "strconv"
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"strconv"
+)
 
 // GenRateLimitCacheKey generate rate limit cache key, limit:{biz}:{target}, e.g: limit:IP:127.0.0.1
 func GenRateLimitCacheKey(mode string, target string) string {
 	return "limit:" + mode + ":" + target
 }
 
+// GenLockCacheKey generate distributed lock cache key, lock:{biz}, e.g: lock:botWebhook
+func GenLockCacheKey(biz string) string {
+	return "lock:" + biz
+}
+
+// GenBotWebhookCacheKey generate bot webhook fingerprint cache key
+func GenBotWebhookCacheKey() string {
+	return "bot:webhook"
+}
+
+// GenNotifyQueueCacheKey generate pending notification queue cache key
+func GenNotifyQueueCacheKey() string {
+	return "notify:queue"
+}
+
+// GenNotifyRetryCacheKey generate delayed retry notification sorted set cache key, score is the retry ts
+func GenNotifyRetryCacheKey() string {
+	return "notify:retry"
+}
+
+// GenNotifyProcessingCacheKey generate list cache key of jobs a notification worker is delivering, notify:processing:{workerId}
+func GenNotifyProcessingCacheKey(workerId string) string {
+	return "notify:processing:" + workerId
+}
+
+// GenNotifyWorkersCacheKey generate set cache key of notification worker ids which may own processing jobs
+func GenNotifyWorkersCacheKey() string {
+	return "notify:workers"
+}
+
+// GenNotifyWorkerAliveCacheKey generate notification worker heartbeat cache key, notify:alive:{workerId}
+func GenNotifyWorkerAliveCacheKey(workerId string) string {
+	return "notify:alive:" + workerId
+}
+
+// GenMomentNotifyDedupeCacheKey generate moment owner notification sent mark cache key, notify:moment:{type}:{momentId}:{fromUid}
+func GenMomentNotifyDedupeCacheKey(notifyType string, momentId int64, fromUid int64) string {
+	return "notify:moment:" + notifyType + ":" + strconv.FormatInt(momentId, 10) + ":" + strconv.FormatInt(fromUid, 10)
+}
+
+// GenCheckinReminderCacheKey generate checkin reminder sent mark cache key, remind:checkin:{date}:{uid}
+func GenCheckinReminderCacheKey(date string, uid int64) string {
+	return "remind:checkin:" + date + ":" + strconv.FormatInt(uid, 10)
+}
+
 func GenCoinPriceCacheKey(fiatSymbol string, coinSymbol string) string {
 	return "price:" + fiatSymbol + ":" + coinSymbol
 }
@@ -20,3 +69,30 @@ func GenUserCacheKey(uid int64) string {
 func GenUserSessionCacheKey(uid int64) string {
 	return "s:" + strconv.FormatInt(uid, 10)
 }
+
+// GenProxyAssetCacheKey generate proxied asset cache key, proxy:asset:{encoding}:{sha1(url)}
+func GenProxyAssetCacheKey(encoding string, url string) string {
+	sum := sha1.Sum([]byte(url))
+	return "proxy:asset:" + encoding + ":" + hex.EncodeToString(sum[:])
+}
+
+// GenProxyAssetStatsCacheKey generate proxied asset cache hit/miss counters hash key
+func GenProxyAssetStatsCacheKey() string {
+	return "proxy:asset:stats"
+}
+
+// GenRpcResultCacheKey generate immutable json-rpc result cache key, rpc:{chain}:{method}:{sha1(params)}
+func GenRpcResultCacheKey(chain string, method string, params string) string {
+	sum := sha1.Sum([]byte(params))
+	return "rpc:" + chain + ":" + method + ":" + hex.EncodeToString(sum[:])
+}
+
+// GenRpcBlockNumberCacheKey generate latest block number cache key of a chain
+func GenRpcBlockNumberCacheKey(chain string) string {
+	return "rpc:" + chain + ":blockNumber"
+}
+
+// GenCoinPriceHistoryCacheKey generate coin usd candles cache key, price:history:{symbol}:{interval}:{from}:{to}
+func GenCoinPriceHistoryCacheKey(coinSymbol string, interval string, from int64, to int64) string {
+	return "price:history:" + coinSymbol + ":" + interval + ":" + strconv.FormatInt(from, 10) + ":" + strconv.FormatInt(to, 10)
+}
@@ -0,0 +1,83 @@
+package caches
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	ProxyAssetStatHit         = "hit"         // served from cache without upstream request
+	ProxyAssetStatRevalidated = "revalidated" // upstream answered 304, served from cache
+	ProxyAssetStatMiss        = "miss"        // fetched from upstream
+	ProxyAssetStatBypass      = "bypass"      // request or response not cacheable
+)
+
+// ProxyAsset A cached upstream response of an embedded site asset
+type ProxyAsset struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+	StoredAt   int64 // unix seconds the response was fetched or revalidated
+	FreshUntil int64 // unix seconds until which no revalidation is needed
+}
+
+// IsFresh whether the asset can be served without revalidation
+func (a *ProxyAsset) IsFresh(now time.Time) bool {
+	return now.Unix() < a.FreshUntil
+}
+
+// ProxyAssetGet find cached asset
+func (s *Service) ProxyAssetGet(key string) (*ProxyAsset, error) {
+	fields, e0 := s.HMapGet(key)
+	if e0 != nil {
+		return nil, e0
+	}
+	if len(fields) == 0 {
+		return nil, errors.New("proxy asset not found")
+	}
+	asset := &ProxyAsset{Body: []byte(fields["body"])}
+	if e1 := json.Unmarshal([]byte(fields["header"]), &asset.Header); e1 != nil {
+		return nil, e1
+	}
+	asset.StatusCode, _ = strconv.Atoi(fields["status"])
+	asset.StoredAt, _ = strconv.ParseInt(fields["storedAt"], 10, 64)
+	asset.FreshUntil, _ = strconv.ParseInt(fields["freshUntil"], 10, 64)
+	return asset, nil
+}
+
+// ProxyAssetSet store asset, it is kept for expiresSec so stale copies can still be revalidated
+func (s *Service) ProxyAssetSet(key string, asset *ProxyAsset, expiresSec int) error {
+	header, e0 := json.Marshal(asset.Header)
+	if e0 != nil {
+		return e0
+	}
+	return s.HMapSet(key, map[string]interface{}{
+		"status":     asset.StatusCode,
+		"header":     header,
+		"body":       asset.Body,
+		"storedAt":   asset.StoredAt,
+		"freshUntil": asset.FreshUntil,
+	}, expiresSec)
+}
+
+// ProxyAssetStatIncr increase a proxy asset cache counter
+func (s *Service) ProxyAssetStatIncr(stat string) {
+	s.RdsInstance.HIncrBy(context.Background(), GenProxyAssetStatsCacheKey(), stat, 1)
+}
+
+// ProxyAssetStats return proxy asset cache counters
+func (s *Service) ProxyAssetStats() (map[string]int64, error) {
+	fields, e0 := s.HMapGet(GenProxyAssetStatsCacheKey())
+	if e0 != nil {
+		return nil, e0
+	}
+	stats := map[string]int64{ProxyAssetStatHit: 0, ProxyAssetStatRevalidated: 0, ProxyAssetStatMiss: 0, ProxyAssetStatBypass: 0}
+	for stat, value := range fields {
+		stats[stat], _ = strconv.ParseInt(value, 10, 64)
+	}
+	return stats, nil
+}
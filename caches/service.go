@@ -12,6 +12,7 @@ import (
 	"github.com/go-redsync/redsync/v4/redis/goredis/v9"
 	"github.com/redis/go-redis/v9"
 	"log"
+	"strconv"
 	"time"
 )
 
@@ -39,11 +40,42 @@ func (s *Service) SetString(key string, item string, expiresSec int) error {
 	return s.RdsInstance.Set(context.Background(), key, item, time.Duration(expiresSec)*time.Second).Err()
 }
 
+// SetStringIfNotExists store a key-value pair only if key not exists, return whether it is stored
+func (s *Service) SetStringIfNotExists(key string, item string, expiresSec int) (bool, error) {
+	return s.RdsInstance.SetNX(context.Background(), key, item, time.Duration(expiresSec)*time.Second).Result()
+}
+
 // GetString try to get a string value by key from Cache service
 func (s *Service) GetString(key string) (string, error) {
 	return s.RdsInstance.Get(context.Background(), key).Result()
 }
 
+// MGetString get string values of keys in one round trip, missing keys are empty strings
+func (s *Service) MGetString(keys ...string) ([]string, error) {
+	values, err := s.RdsInstance.MGet(context.Background(), keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+	items := make([]string, len(values))
+	for i, value := range values {
+		if str, ok := value.(string); ok {
+			items[i] = str
+		}
+	}
+	return items, nil
+}
+
+// MSetString store key-value pairs with the same expiration in one pipeline
+func (s *Service) MSetString(items map[string]string, expiresSec int) error {
+	_, err := s.RdsInstance.Pipelined(context.Background(), func(pipe redis.Pipeliner) error {
+		for key, item := range items {
+			pipe.Set(context.Background(), key, item, time.Duration(expiresSec)*time.Second)
+		}
+		return nil
+	})
+	return err
+}
+
 // Delete try to delete al value in Cache service
 func (s *Service) Delete(key string) {
 	s.RdsInstance.Del(context.Background(), key)
@@ -125,7 +157,58 @@ func (s *Service) SSetDel(key string, value interface{}) error {
 	}
 }
 
+// SSetMembers Redis SMEMBERS to query all values in a set
+func (s *Service) SSetMembers(key string) ([]string, error) {
+	return s.RdsInstance.SMembers(context.Background(), key).Result()
+}
+
 // SSetCount Redis SCARD to query count in a set
 func (s *Service) SSetCount(key string) (int64, error) {
 	return s.RdsInstance.SCard(context.Background(), key).Result()
 }
+
+// ListPush Redis LPUSH to push values to the head of a list
+func (s *Service) ListPush(key string, values ...interface{}) error {
+	return s.RdsInstance.LPush(context.Background(), key, values...).Err()
+}
+
+// ListBlockMove Redis BLMOVE to move a value from the tail of src list to the head of dst list atomically, block until
+// timeout, return redis.Nil if timeout
+func (s *Service) ListBlockMove(src string, dst string, timeout time.Duration) (string, error) {
+	return s.RdsInstance.BLMove(context.Background(), src, dst, "RIGHT", "LEFT", timeout).Result()
+}
+
+// ListMove Redis LMOVE to move a value from the tail of src list to the head of dst list, return redis.Nil if src is empty
+func (s *Service) ListMove(src string, dst string) (string, error) {
+	return s.RdsInstance.LMove(context.Background(), src, dst, "RIGHT", "LEFT").Result()
+}
+
+// ListRemove Redis LREM to remove one occurrence of value from a list
+func (s *Service) ListRemove(key string, value interface{}) error {
+	return s.RdsInstance.LRem(context.Background(), key, 1, value).Err()
+}
+
+// ZSetAdd Redis ZADD to add a member with score in sorted set
+func (s *Service) ZSetAdd(key string, score float64, member interface{}) error {
+	return s.RdsInstance.ZAdd(context.Background(), key, redis.Z{Score: score, Member: member}).Err()
+}
+
+// ZSetPopByScore Pop members whose score <= maxScore in sorted set, each member is only popped by one caller
+func (s *Service) ZSetPopByScore(key string, maxScore float64, count int64) ([]string, error) {
+	members, e0 := s.RdsInstance.ZRangeByScore(context.Background(), key, &redis.ZRangeBy{
+		Min:   "-inf",
+		Max:   strconv.FormatFloat(maxScore, 'f', -1, 64),
+		Count: count,
+	}).Result()
+	if e0 != nil {
+		return nil, e0
+	}
+	var popped []string
+	for _, member := range members {
+		// only the caller which removed the member successfully owns it
+		if removed, e1 := s.RdsInstance.ZRem(context.Background(), key, member).Result(); e1 == nil && removed > 0 {
+			popped = append(popped, member)
+		}
+	}
+	return popped, nil
+}
@@ -11,6 +11,11 @@ const (
 	FiatUSD = "USD"
 )
 
+const (
+	BotModePolling = "polling"
+	BotModeWebhook = "webhook"
+)
+
 const (
 	CurUser = "c_u" // key for user data in middleware
 )
@@ -39,4 +44,92 @@ const (
 
 	TaskSocialBaseRewardPoint = int64(10)
 	TaskWalletBaseRewardPoint = int64(50)
+
+	MiningBaseRatePerHour = int64(10)
+	MiningBaseCapacity    = int64(80)
+
+	MiningUpgradeRate      = "rate"
+	MiningUpgradeCapacity  = "capacity"
+	MiningUpgradeAutoClaim = "autoClaim"
+)
+
+const (
+	TapMaxEnergy         = int64(1000)
+	TapEnergyRegenPerSec = int64(3)
+	TapPointsPerTap      = int64(1)
+	TapMaxTapsPerSec     = int64(15)
+	TapFlagAfterRejects  = int64(10)
+	TapFlagWindowSec     = int64(24 * 3600)
+)
+
+const (
+	BoosterTypeMultiplier   = "multiplier"
+	BoosterTypeEnergyRefill = "energyRefill"
+
+	BoosterSourceShop = "shop"
+	BoosterSourceTask = "task"
+)
+
+const (
+	DailyRewardPoints = 200
+
+	PremiumCheckinMultiplier   = 1.5
+	PremiumDailyRewardPoints   = 400
+	PremiumExtraMiningCapacity = int64(40)
+)
+
+const (
+	NotifyTypeMomentLike      = "momentLike"
+	NotifyTypeMomentComment   = "momentComment"
+	NotifyTypeMomentReward    = "momentReward"
+	NotifyTypeCheckinReminder = "checkinReminder"
+	NotifyTypePriceAboveAlert = "priceAboveAlert"
+	NotifyTypePriceBelowAlert = "priceBelowAlert"
+	NotifyTypeAchievement     = "achievement"
+)
+
+const (
+	AchievementTypeCheckinStreak = "checkinStreak"
+	AchievementTypeMoments       = "moments"
+	AchievementTypeInvites       = "invites"
+	AchievementTypeRank          = "rank"
+
+	AchievementEventCheckin = "checkin"
+	AchievementEventTask    = "task"
+	AchievementEventMoment  = "moment"
+	AchievementEventInvite  = "invite"
+	AchievementEventPoints  = "points"
+)
+
+const (
+	BroadcastStatusRunning   = 0
+	BroadcastStatusPaused    = 1
+	BroadcastStatusCancelled = 2
+	BroadcastStatusFinished  = 3
+
+	BroadcastDeliveryDelivered = 1
+	BroadcastDeliveryBlocked   = 2
+	BroadcastDeliveryFailed    = 3
+)
+
+const (
+	PriceProviderBinance   = "binance"
+	PriceProviderCoinGecko = "coingecko"
+	PriceProviderDex       = "dex"
+
+	PriceStrategyMedian   = "median"
+	PriceStrategyFallback = "fallback"
+)
+
+const (
+	PriceInterval1h = "1h"
+	PriceInterval1d = "1d"
+)
+
+const (
+	PriceAlertDirectionAbove = 1
+	PriceAlertDirectionBelow = 2
+
+	PriceAlertStatusActive    = 0
+	PriceAlertStatusTriggered = 1
 )
@@ -26,12 +26,187 @@ type CacheConfig struct {
 }
 
 type BotConfig struct {
-	WebUrl string `json:"webUrl"`
+	WebUrl        string  `json:"webUrl"`
+	Mode          string  `json:"mode"`          // bot updates mode: polling, webhook, polling by default
+	WebhookHost   string  `json:"webhookHost"`   // public base url telegram calls in webhook mode: https://api.example.com
+	WebhookPath   string  `json:"webhookPath"`   // webhook route served by http server: /bot/webhook
+	WebhookSecret string  `json:"webhookSecret"` // secret token telegram sends in X-Telegram-Bot-Api-Secret-Token header
+	AdminUids     []int64 `json:"adminUids"`     // telegram user ids allowed to use admin commands and apis
+}
+
+// IsAdmin check whether a user is admin
+func (c *BotConfig) IsAdmin(uid int64) bool {
+	for _, adminUid := range c.AdminUids {
+		if adminUid == uid {
+			return true
+		}
+	}
+	return false
+}
+
+// NotifyConfig Bot notification delivery config, zero values fallback to defaults
+type NotifyConfig struct {
+	Workers                    int `json:"workers"`                    // queue consumer count in each replica: 2
+	MaxRetries                 int `json:"maxRetries"`                 // max retry times for a failed notification: 5
+	GlobalRatePerSec           int `json:"globalRatePerSec"`           // max bot messages per second across all replicas: 25
+	CheckinReminderIntervalSec int `json:"checkinReminderIntervalSec"` // checkin reminder scan interval in seconds: 600
+	CheckinReminderLeadSec     int `json:"checkinReminderLeadSec"`     // remind users this many seconds before their checkin streak breaks: 14400
+	PriceAlertIntervalSec      int `json:"priceAlertIntervalSec"`      // interval in seconds to refresh prices of coins with active alerts: 30
+}
+
+// MiningConfig Idle mining config, zero values fallback to defaults
+type MiningConfig struct {
+	BaseRatePerHour int64                  `json:"baseRatePerHour"` // points mined per hour before upgrades: 10
+	BaseCapacity    int64                  `json:"baseCapacity"`    // max unclaimed points before upgrades, mining stops when storage is full: 80
+	Upgrades        []*MiningUpgradeConfig `json:"upgrades"`        // upgrades sold in shop, default upgrades are used if empty
+}
+
+// TapConfig Tap to earn config, zero values fallback to defaults
+type TapConfig struct {
+	MaxEnergy         int64 `json:"maxEnergy"`         // energy pool size, each tap costs 1 energy: 1000
+	EnergyRegenPerSec int64 `json:"energyRegenPerSec"` // energy regenerated per second: 3
+	PointsPerTap      int64 `json:"pointsPerTap"`      // points credited per tap: 1
+	MaxTapsPerSec     int64 `json:"maxTapsPerSec"`     // max human tap rate, faster batches are rejected: 15
+	FlagAfterRejects  int64 `json:"flagAfterRejects"`  // user is flagged and can not tap after this many rejected batches in a window: 10
+	FlagWindowSec     int64 `json:"flagWindowSec"`     // seconds rejected batches are counted in, counting restarts after it: 86400
+}
+
+// PremiumConfig Perks of Telegram Premium users, zero values fallback to defaults
+type PremiumConfig struct {
+	CheckinMultiplier   float64 `json:"checkinMultiplier"`   // checkin reward multiplier: 1.5
+	DailyRewardPoints   int     `json:"dailyRewardPoints"`   // reward points allowance refreshed everyday: 400
+	ExtraMiningCapacity int64   `json:"extraMiningCapacity"` // mining capacity added on top of upgrades: 40
+}
+
+// AchievementConfig An achievement awarding a badge when progress of its type reaches target
+type AchievementConfig struct {
+	Id          string `json:"id"`          // badge id: streak7
+	Name        string `json:"name"`        // badge name shown to users: 7-Day Streak
+	Type        string `json:"type"`        // progress type: checkinStreak, moments, invites, rank
+	Target      int64  `json:"target"`      // progress to reach, for rank it is the lowest rank to reach: 100
+	RewardPoint int64  `json:"rewardPoint"` // points awarded with the badge, 0 for none
+}
+
+// BoosterConfig A booster users buy with points or get from tasks
+type BoosterConfig struct {
+	Id             string   `json:"id"`             // booster id: points2x
+	Type           string   `json:"type"`           // booster type: multiplier, energyRefill
+	Multiplier     float64  `json:"multiplier"`     // checkin, task, wallet and invite points multiplier while active, boosters do not stack: 2
+	DurationSec    int64    `json:"durationSec"`    // how long a multiplier lasts, activating it again while active extends it: 3600
+	Cost           int64    `json:"cost"`           // points cost, 0 if it is not sold in shop
+	GrantedByTasks []string `json:"grantedByTasks"` // task types granting the booster when claimed: socialRtAnn
+}
+
+// MiningUpgradeConfig An upgrade with levels, cost of level n is baseCost * costGrowth^(n-1)
+type MiningUpgradeConfig struct {
+	Type       string  `json:"type"`       // upgrade type: rate, capacity, autoClaim
+	MaxLevel   int     `json:"maxLevel"`   // max level: 10
+	BaseCost   int64   `json:"baseCost"`   // points cost of level 1: 100
+	CostGrowth float64 `json:"costGrowth"` // cost multiplier of each next level: 1.6
+	StepValue  int64   `json:"stepValue"`  // value each level adds: points per hour for rate, points for capacity, seconds for autoClaim
+}
+
+// ProxySiteConfig An embedded dApp site served by proxy under a route prefix
+type ProxySiteConfig struct {
+	Name            string              `json:"name"`            // site name: pancakeswap
+	Prefix          string              `json:"prefix"`          // route prefix served by proxy: /_next, sites sharing a prefix are matched by Referer
+	Origin          string              `json:"origin"`          // upstream origin: https://pancakeswap.finance
+	PathRewrites    []*ProxyPathRewrite `json:"pathRewrites"`    // request path prefix rewrites before forwarding to origin, first matched wins
+	RewritePaths    []string            `json:"rewritePaths"`    // relative paths rewritten to absolute origin urls in proxied html: /_next, /favicon.ico
+	RequestHeaders  map[string]string   `json:"requestHeaders"`  // headers set on upstream requests, empty value removes the header
+	ResponseHeaders map[string]string   `json:"responseHeaders"` // headers set on responses to client, empty value removes the header
+}
+
+// ProxyPathRewrite Replace path prefix From with To
+type ProxyPathRewrite struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// ProxyConfig Proxy endpoints config, requests to private, loopback and link-local addresses are always blocked
+type ProxyConfig struct {
+	AllowedHosts            []string           `json:"allowedHosts"`            // allowed target hosts, "*.example.com" matches subdomains, empty allows all public hosts
+	AllowedSchemes          []string           `json:"allowedSchemes"`          // allowed target url schemes: http, https
+	AllowPrivateNetwork     bool               `json:"allowPrivateNetwork"`     // allow private network targets, local develop only
+	ConnectTimeoutSec       int                `json:"connectTimeoutSec"`       // upstream connect and tls handshake timeout in seconds: 10
+	ReadTimeoutSec          int                `json:"readTimeoutSec"`          // upstream response header and body idle read timeout in seconds: 30
+	MaxRequestBodyBytes     int64              `json:"maxRequestBodyBytes"`     // max request body size forwarded to upstream: 1MB
+	MaxResponseBodyBytes    int64              `json:"maxResponseBodyBytes"`    // max upstream response body size: 20MB
+	Sites                   []*ProxySiteConfig `json:"sites"`                   // embedded dApp sites, pancakeswap on /_next by default
+	InjectScriptUrl         string             `json:"injectScriptUrl"`         // script injected into the head of proxied html pages, empty for none
+	BannerHtml              string             `json:"bannerHtml"`              // html snippet injected at the start of proxied html body, empty for none
+	DisableAssetCache       bool               `json:"disableAssetCache"`       // disable redis cache of embedded site assets
+	AssetCacheMaxAgeSec     int                `json:"assetCacheMaxAgeSec"`     // max seconds a cached asset is kept for revalidation: 86400
+	AssetCacheMaxEntryBytes int64              `json:"assetCacheMaxEntryBytes"` // assets larger than this are not cached: 2MB
+	WsIdleTimeoutSec        int                `json:"wsIdleTimeoutSec"`        // close proxied websocket if no frame flows within seconds: 120
+	WsMaxConnsPerIp         int                `json:"wsMaxConnsPerIp"`         // max concurrent proxied websockets of a client ip: 10
+}
+
+// RpcChainConfig A chain served by rpc gateway
+type RpcChainConfig struct {
+	Name    string   `json:"name"`    // route name, e.g: bsc for /rpc/bsc
+	Nodes   []string `json:"nodes"`   // upstream node urls, tried in order, unhealthy ones are skipped for a while
+	Methods []string `json:"methods"` // allowed json-rpc methods, empty for the default read and send methods
+}
+
+// RpcConfig Json-rpc gateway config
+type RpcConfig struct {
+	Chains             []*RpcChainConfig `json:"chains"`
+	TimeoutSec         int               `json:"timeoutSec"`         // upstream node request timeout in seconds: 10
+	NodeCooldownSec    int               `json:"nodeCooldownSec"`    // seconds a failed node is skipped: 30
+	UserRatePerMin     int               `json:"userRatePerMin"`     // json-rpc calls per user per minute, a batch counts every call: 600
+	MaxBatchSize       int               `json:"maxBatchSize"`       // max calls in a batch: 50
+	ConfirmationBlocks int64             `json:"confirmationBlocks"` // blocks after which a receipt is cached as immutable: 15
+}
+
+// PriceDexPoolConfig A uniswap v2 style pair used as price source of a coin
+type PriceDexPoolConfig struct {
+	Symbol        string `json:"symbol"`        // coin symbol, e.g: CAKE
+	RpcUrl        string `json:"rpcUrl"`        // chain json-rpc node url
+	Pair          string `json:"pair"`          // pair contract address
+	BaseIsToken0  bool   `json:"baseIsToken0"`  // whether the coin is token0 of the pair, the other token is a usd stablecoin
+	BaseDecimals  int    `json:"baseDecimals"`  // coin decimals
+	QuoteDecimals int    `json:"quoteDecimals"` // stablecoin decimals
+}
+
+// PriceProviderConfig A coin price source
+type PriceProviderConfig struct {
+	Name    string                `json:"name"`    // provider name shown in health status
+	Type    string                `json:"type"`    // binance, coingecko or dex
+	BaseUrl string                `json:"baseUrl"` // api base url, default public endpoint of the type
+	ApiKey  string                `json:"apiKey"`  // optional api key
+	Symbols map[string]string     `json:"symbols"` // coin symbol to provider id, binance pair (BNBUSDT) or coingecko id (binancecoin)
+	Pools   []*PriceDexPoolConfig `json:"pools"`   // dex pools, dex type only
+}
+
+// PriceConfig Coin price oracle config
+type PriceConfig struct {
+	Strategy            string                 `json:"strategy"`            // median of all providers, or fallback to the next provider in order
+	Providers           []*PriceProviderConfig `json:"providers"`           // empty for binance only
+	TimeoutSec          int                    `json:"timeoutSec"`          // provider request timeout in seconds: 10
+	FailureThreshold    int                    `json:"failureThreshold"`    // consecutive failures after which a provider is skipped: 3
+	CooldownSec         int                    `json:"cooldownSec"`         // seconds an unhealthy provider is skipped: 60
+	Fiats               []string               `json:"fiats"`               // supported fiat symbols, empty for the default list
+	FxBaseUrl           string                 `json:"fxBaseUrl"`           // fiat exchange rate api base url: https://open.er-api.com
+	FxRefreshSec        int                    `json:"fxRefreshSec"`        // fiat exchange rate refresh interval in seconds: 3600
+	Watchlist           []string               `json:"watchlist"`           // coin symbols kept fresh in cache by background refresher
+	WatchlistRefreshSec int                    `json:"watchlistRefreshSec"` // watchlist refresh interval in seconds: 20
+	CacheTtlSec         int                    `json:"cacheTtlSec"`         // seconds a fetched price is cached: 60
+	StreamRefreshSec    int                    `json:"streamRefreshSec"`    // seconds between price pushes to stream subscribers: 5
 }
 
 type Config struct {
-	Basic    *BasicConfig    `json:"basic"`
-	Database *DatabaseConfig `json:"database"`
-	Cache    *CacheConfig    `json:"cache"`
-	Bot      *BotConfig      `json:"bot"`
+	Basic        *BasicConfig         `json:"basic"`
+	Database     *DatabaseConfig      `json:"database"`
+	Cache        *CacheConfig         `json:"cache"`
+	Bot          *BotConfig           `json:"bot"`
+	Notify       *NotifyConfig        `json:"notify"`
+	Mining       *MiningConfig        `json:"mining"`
+	Tap          *TapConfig           `json:"tap"`
+	Boosters     []*BoosterConfig     `json:"boosters"` // boosters in shop, default boosters are used if empty
+	Premium      *PremiumConfig       `json:"premium"`
+	Achievements []*AchievementConfig `json:"achievements"` // achievements, default achievements are used if empty
+	Proxy        *ProxyConfig         `json:"proxy"`
+	Rpc          *RpcConfig           `json:"rpc"`
+	Price        *PriceConfig         `json:"price"`
 }
@@ -49,6 +49,51 @@ func initSqlIfNeed(cfg *configs.DatabaseConfig, dbName string) {
 	log.Printf("Init DB exec %d sqls\n", len(sqlList))
 }
 
+// addedColumns columns added to tables of an existing database, init.sql only creates missing tables
+var addedColumns = []struct {
+	table      string
+	column     string
+	definition string
+}{
+	{"points", "total_mining_point_value", "BIGINT NOT NULL DEFAULT 0"},
+	{"points", "total_tap_point_value", "BIGINT NOT NULL DEFAULT 0"},
+	{"points", "total_achievement_point_value", "BIGINT NOT NULL DEFAULT 0"},
+	{"taps", "window_rejects", "BIGINT NOT NULL DEFAULT 0"},
+	{"taps", "window_started_at", "BIGINT NOT NULL DEFAULT 0"},
+}
+
+// addedIndexes indexes added to tables of an existing database
+var addedIndexes = []struct {
+	table   string
+	name    string
+	columns string
+}{
+	{"checkins", "CREATED_UID", "created_at, uid"},
+}
+
+// migrateSchema add columns and indexes missing in tables created by an earlier init.sql, existing ones are skipped
+func migrateSchema(db *gorm.DB) error {
+	for _, c := range addedColumns {
+		if !db.Migrator().HasTable(c.table) || db.Migrator().HasColumn(c.table, c.column) {
+			continue
+		}
+		if e0 := db.Exec(fmt.Sprintf("ALTER TABLE `%s` ADD COLUMN `%s` %s", c.table, c.column, c.definition)).Error; e0 != nil {
+			return fmt.Errorf("add column %s.%s: %w", c.table, c.column, e0)
+		}
+		log.Printf("Migrate DB added column %s.%s\n", c.table, c.column)
+	}
+	for _, index := range addedIndexes {
+		if !db.Migrator().HasTable(index.table) || db.Migrator().HasIndex(index.table, index.name) {
+			continue
+		}
+		if e1 := db.Exec(fmt.Sprintf("CREATE INDEX `%s` ON `%s` (%s)", index.name, index.table, index.columns)).Error; e1 != nil {
+			return fmt.Errorf("add index %s.%s: %w", index.table, index.name, e1)
+		}
+		log.Printf("Migrate DB added index %s.%s\n", index.table, index.name)
+	}
+	return nil
+}
+
 func createDBInstance(cfg *configs.DatabaseConfig, dbname string) (*gorm.DB, error) {
 	dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8&parseTime=True", cfg.User, cfg.Pass, cfg.Host, cfg.Port, dbname)
 	gormDB, e0 := gorm.Open(mysql.Open(dsn), &gorm.Config{})
@@ -75,5 +120,8 @@ func CreateDBService(cfg *configs.DatabaseConfig, env string) *Service {
 	if e2 != nil {
 		log.Panicf("create DB connect failed: %s, host: %s", e2, cfg.Host)
 	}
+	if e3 := migrateSchema(dbInstance); e3 != nil {
+		log.Panicf("Migrate DB failed: %s", e3)
+	}
 	return &Service{DBInstance: dbInstance}
 }
@@ -0,0 +1,201 @@
+package dbs
+
+import (
+	"errors"
+	"game-mining-server/configs"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"slices"
+)
+
+// defaultAchievements achievements when none is configured
+var defaultAchievements = []*configs.AchievementConfig{
+	{Id: "streak7", Name: "7-Day Streak", Type: configs.AchievementTypeCheckinStreak, Target: 7, RewardPoint: 100},
+	{Id: "firstMoment", Name: "First Moment", Type: configs.AchievementTypeMoments, Target: 1, RewardPoint: 20},
+	{Id: "invites10", Name: "Connector", Type: configs.AchievementTypeInvites, Target: 10, RewardPoint: 200},
+	{Id: "top100", Name: "Top 100", Type: configs.AchievementTypeRank, Target: 100, RewardPoint: 500},
+}
+
+// achievementEvents event -> progress types the event may change
+var achievementEvents = map[string][]string{
+	configs.AchievementEventCheckin: {configs.AchievementTypeCheckinStreak, configs.AchievementTypeRank},
+	configs.AchievementEventTask:    {configs.AchievementTypeRank},
+	configs.AchievementEventMoment:  {configs.AchievementTypeMoments},
+	configs.AchievementEventInvite:  {configs.AchievementTypeInvites, configs.AchievementTypeRank},
+	configs.AchievementEventPoints:  {configs.AchievementTypeRank},
+}
+
+// Achievement a badge awarded to user
+type Achievement struct {
+	Id            int64  `gorm:"primaryKey;autoIncrement" json:"id"`    // award id
+	CreatedAt     int64  `gorm:"autoCreateTime:milli" json:"createdAt"` // awarded ts: 1670400478555
+	Uid           int64  `gorm:"type:bigint" json:"uid"`                // badge owner user id
+	AchievementId string `gorm:"type:varchar(64)" json:"achievementId"` // achievement id in config: streak7
+	Name          string `gorm:"type:varchar(255)" json:"name"`         // badge name when awarded: 7-Day Streak
+	RewardPoint   int64  `gorm:"type:bigint" json:"rewardPoint"`        // points awarded with the badge
+}
+
+// AchievementProgress an achievement with user's progress
+type AchievementProgress struct {
+	*configs.AchievementConfig
+	Progress  int64 `json:"progress"`            // current progress, current rank for rank type and 0 if user has no points
+	Achieved  bool  `json:"achieved"`            // whether the badge is awarded
+	AwardedAt int64 `json:"awardedAt,omitempty"` // awarded ts: 1670400478555
+}
+
+func (u *Achievement) TableName() string {
+	return "achievements"
+}
+
+// Achievements all achievements
+func Achievements(config *configs.Config) []*configs.AchievementConfig {
+	if config != nil && len(config.Achievements) > 0 {
+		return config.Achievements
+	}
+	return defaultAchievements
+}
+
+// achievementReached whether progress reaches target of achievement, a rank reaches target when it is not lower
+func achievementReached(achievement *configs.AchievementConfig, progress int64) bool {
+	if achievement.Type == configs.AchievementTypeRank {
+		return progress > 0 && progress <= achievement.Target
+	}
+	return progress >= achievement.Target
+}
+
+// achievementProgress user's current progress of a progress type
+func (s *Service) achievementProgress(db *gorm.DB, uid int64, progressType string, config *configs.Config) (int64, error) {
+	switch progressType {
+	case configs.AchievementTypeCheckinStreak:
+		// the streak of latest claimed checkin, unless it is already broken
+		var checkin Checkin
+		e0 := db.Where("uid = ? AND status = ?", uid, configs.CheckinStatusClaimed).Order("created_at desc").First(&checkin).Error
+		if errors.Is(e0, gorm.ErrRecordNotFound) {
+			return 0, nil
+		} else if e0 != nil {
+			return 0, e0
+		}
+		if checkin.CreatedAt < getBaseTimeTs(config.Basic)-int64(config.Basic.CheckinBrokenSec*1000) {
+			return 0, nil
+		}
+		return int64(checkin.ContinuousDays), nil
+	case configs.AchievementTypeMoments:
+		var count int64
+		e1 := db.Model(&Moment{}).Where("user_id = ?", uid).Count(&count).Error
+		return count, e1
+	case configs.AchievementTypeInvites:
+		var count int64
+		e2 := db.Model(&User{}).Where("referral_uid = ?", uid).Count(&count).Error
+		return count, e2
+	case configs.AchievementTypeRank:
+		// rank on leaderboard, users with the same points share a rank
+		var point Point
+		e3 := db.Where("uid = ?", uid).First(&point).Error
+		if errors.Is(e3, gorm.ErrRecordNotFound) || (e3 == nil && point.TotalPointValue <= 0) {
+			return 0, nil
+		} else if e3 != nil {
+			return 0, e3
+		}
+		var higher int64
+		e4 := db.Model(&Point{}).Where("total_point_value > ?", point.TotalPointValue).Count(&higher).Error
+		return higher + 1, e4
+	default:
+		return 0, nil
+	}
+}
+
+// AchievementFindByUid find a user's badges, newest first
+func (s *Service) AchievementFindByUid(uid int64) ([]*Achievement, error) {
+	var achievements []*Achievement
+	if e := s.DBInstance.Where("uid = ?", uid).Order("created_at desc").Find(&achievements).Error; e != nil {
+		return nil, e
+	} else {
+		return achievements, nil
+	}
+}
+
+// AchievementFindProgress all achievements with a user's progress and award ts
+func (s *Service) AchievementFindProgress(uid int64, config *configs.Config) ([]*AchievementProgress, error) {
+	awarded, e0 := s.AchievementFindByUid(uid)
+	if e0 != nil {
+		return nil, e0
+	}
+	awardedAt := make(map[string]int64, len(awarded))
+	for _, achievement := range awarded {
+		awardedAt[achievement.AchievementId] = achievement.CreatedAt
+	}
+
+	progresses := make(map[string]int64)
+	achievements := Achievements(config)
+	result := make([]*AchievementProgress, 0, len(achievements))
+	for _, achievement := range achievements {
+		progress, ok := progresses[achievement.Type]
+		if !ok {
+			_progress, e1 := s.achievementProgress(s.DBInstance, uid, achievement.Type, config)
+			if e1 != nil {
+				return nil, e1
+			}
+			progress, progresses[achievement.Type] = _progress, _progress
+		}
+		item := &AchievementProgress{AchievementConfig: achievement, Progress: progress}
+		item.AwardedAt, item.Achieved = awardedAt[achievement.Id]
+		result = append(result, item)
+	}
+	return result, nil
+}
+
+// AchievementEvaluate award badges of achievements whose progress may be changed by event and reaches target,
+// points awarded with badges are added to user's point. Return newly awarded badges
+func (s *Service) AchievementEvaluate(uid int64, event string, config *configs.Config) ([]*Achievement, error) {
+	progressTypes := achievementEvents[event]
+	if len(progressTypes) == 0 {
+		return nil, nil
+	}
+
+	var awarded []*Achievement
+	e := s.DBInstance.Transaction(func(tx *gorm.DB) error {
+		var awardedIds []string
+		if e0 := tx.Model(&Achievement{}).Where("uid = ?", uid).Pluck("achievement_id", &awardedIds).Error; e0 != nil {
+			return e0
+		}
+
+		progresses := make(map[string]int64)
+		for _, item := range Achievements(config) {
+			if !slices.Contains(progressTypes, item.Type) || slices.Contains(awardedIds, item.Id) {
+				continue
+			}
+			progress, ok := progresses[item.Type]
+			if !ok {
+				_progress, e1 := s.achievementProgress(tx, uid, item.Type, config)
+				if e1 != nil {
+					return e1
+				}
+				progress, progresses[item.Type] = _progress, _progress
+			}
+			if !achievementReached(item, progress) {
+				continue
+			}
+
+			// unique index keeps a badge from being awarded twice by concurrent evaluations
+			achievement := &Achievement{Uid: uid, AchievementId: item.Id, Name: item.Name, RewardPoint: item.RewardPoint}
+			result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(achievement)
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				continue
+			}
+			if item.RewardPoint > 0 {
+				if _, e2 := s.PointClaimForAchievement(tx, uid, item.RewardPoint); e2 != nil {
+					return e2
+				}
+			}
+			awarded = append(awarded, achievement)
+		}
+		return nil
+	})
+	if e != nil {
+		return nil, e
+	}
+	return awarded, nil
+}
@@ -0,0 +1,160 @@
+package dbs
+
+import (
+	"errors"
+	"game-mining-server/configs"
+	"game-mining-server/utils"
+	"gorm.io/gorm"
+	"math"
+	"slices"
+	"time"
+)
+
+var (
+	ErrBoosterNotFound   = errors.New("booster not found")
+	ErrBoosterNotForSale = errors.New("booster is not for sale")
+)
+
+// defaultBoosters boosters in shop when none is configured
+var defaultBoosters = []*configs.BoosterConfig{
+	{Id: "points2x", Type: configs.BoosterTypeMultiplier, Multiplier: 2, DurationSec: 3600, Cost: 500, GrantedByTasks: []string{configs.TaskTypeSocialRtAnn}},
+	{Id: "energyRefill", Type: configs.BoosterTypeEnergyRefill, Cost: 200},
+}
+
+type Booster struct {
+	Id         int64   `gorm:"primaryKey;autoIncrement" json:"id"`    // activation id
+	CreatedAt  int64   `gorm:"autoCreateTime:milli" json:"createdAt"` // created ts: 1670400478555
+	UpdatedAt  int64   `gorm:"autoUpdateTime:milli" json:"updatedAt"` // updated ts: 1670400478555
+	Uid        int64   `gorm:"type:bigint" json:"uid"`                // booster user id
+	BoosterId  string  `gorm:"type:varchar(64)" json:"boosterId"`     // booster id in config: points2x
+	Type       string  `gorm:"type:varchar(32)" json:"type"`          // booster type: multiplier, energyRefill
+	Multiplier float64 `gorm:"type:double" json:"multiplier"`         // points multiplier while active, 1 for other types
+	Source     string  `gorm:"type:varchar(32)" json:"source"`        // how user got the booster: shop, task
+	ExpiresAt  int64   `gorm:"type:bigint" json:"expiresAt"`          // expires ts: 1670400478555, same as created for instant boosters
+}
+
+// ActiveBooster a booster with its remaining duration
+type ActiveBooster struct {
+	*Booster
+	RemainingSec int64 `json:"remainingSec"`
+}
+
+func (u *Booster) TableName() string {
+	return "boosters"
+}
+
+// Boosters boosters in shop
+func Boosters(config *configs.Config) []*configs.BoosterConfig {
+	if config != nil && len(config.Boosters) > 0 {
+		return config.Boosters
+	}
+	return defaultBoosters
+}
+
+// BoosterFindActive find a user's boosters active at nowMs, with their remaining duration
+func (s *Service) BoosterFindActive(db *gorm.DB, uid int64, nowMs int64) ([]*ActiveBooster, error) {
+	var boosters []*Booster
+	if e := db.Where("uid = ? AND expires_at > ?", uid, nowMs).Order("expires_at asc").Find(&boosters).Error; e != nil {
+		return nil, e
+	}
+	active := make([]*ActiveBooster, 0, len(boosters))
+	for _, booster := range boosters {
+		active = append(active, &ActiveBooster{Booster: booster, RemainingSec: (booster.ExpiresAt - nowMs + 999) / 1000})
+	}
+	return active, nil
+}
+
+// boostPoint multiply points by the largest multiplier active now, return boosted points and the multiplier
+func (s *Service) boostPoint(db *gorm.DB, uid int64, point int64) (int64, float64, error) {
+	active, e0 := s.BoosterFindActive(db, uid, time.Now().UnixMilli())
+	if e0 != nil {
+		return 0, 0, e0
+	}
+	multiplier := 1.0
+	for _, booster := range active {
+		if booster.Type == configs.BoosterTypeMultiplier {
+			multiplier = math.Max(multiplier, booster.Multiplier)
+		}
+	}
+	return utils.CalPointWithMultiplier(point, multiplier), multiplier, nil
+}
+
+// activateBooster apply a booster to user, an active multiplier of the same booster is extended instead of added
+func (s *Service) activateBooster(db *gorm.DB, uid int64, item *configs.BoosterConfig, source string, config *configs.Config) (*Booster, error) {
+	nowMs := time.Now().UnixMilli()
+	booster := &Booster{Uid: uid, BoosterId: item.Id, Type: item.Type, Multiplier: 1, Source: source, ExpiresAt: nowMs}
+	switch item.Type {
+	case configs.BoosterTypeMultiplier:
+		var active Booster
+		e0 := db.Where("uid = ? AND booster_id = ? AND expires_at > ?", uid, item.Id, nowMs).Order("expires_at desc").First(&active).Error
+		if e0 == nil {
+			active.ExpiresAt = active.ExpiresAt + item.DurationSec*1000
+			return &active, db.Save(&active).Error
+		} else if !errors.Is(e0, gorm.ErrRecordNotFound) {
+			return nil, e0
+		}
+		booster.Multiplier = item.Multiplier
+		booster.ExpiresAt = nowMs + item.DurationSec*1000
+	case configs.BoosterTypeEnergyRefill:
+		var tap Tap
+		if e1 := findOrCreateTapForUpdate(db, uid, config.Tap, &tap); e1 != nil {
+			return nil, e1
+		}
+		tap.Energy, tap.EnergyUpdatedAt = tapSettings(config.Tap).MaxEnergy, nowMs
+		if e2 := db.Save(&tap).Error; e2 != nil {
+			return nil, e2
+		}
+	default:
+		return nil, ErrBoosterNotFound
+	}
+	return booster, db.Create(booster).Error
+}
+
+// grantTaskBoosters activate boosters granted by claiming a task of taskType
+func (s *Service) grantTaskBoosters(db *gorm.DB, uid int64, taskType string, config *configs.Config) error {
+	for _, item := range Boosters(config) {
+		if slices.Contains(item.GrantedByTasks, taskType) {
+			if _, e0 := s.activateBooster(db, uid, item, configs.BoosterSourceTask, config); e0 != nil {
+				return e0
+			}
+		}
+	}
+	return nil
+}
+
+// BoosterBuy buy a booster in shop with points and activate it
+func (s *Service) BoosterBuy(uid int64, boosterId string, config *configs.Config) (*Booster, *Point, error) {
+	var item *configs.BoosterConfig
+	for _, booster := range Boosters(config) {
+		if booster.Id == boosterId {
+			item = booster
+		}
+	}
+	if item == nil {
+		return nil, nil, ErrBoosterNotFound
+	}
+	if item.Cost <= 0 {
+		return nil, nil, ErrBoosterNotForSale
+	}
+
+	var booster *Booster
+	var point Point
+	e := s.DBInstance.Transaction(func(tx *gorm.DB) error {
+		if e0 := findOrCreatePointForUpdate(tx, uid, &point); e0 != nil {
+			return e0
+		}
+		if e1 := s.PointSpend(tx, uid, item.Cost); e1 != nil {
+			return e1
+		}
+		if _booster, e2 := s.activateBooster(tx, uid, item, configs.BoosterSourceShop, config); e2 != nil {
+			return e2
+		} else {
+			booster = _booster
+		}
+		return tx.Where("uid = ?", uid).First(&point).Error
+	})
+	if e != nil {
+		return nil, nil, e
+	}
+	return booster, &point, nil
+}
@@ -0,0 +1,158 @@
+package dbs
+
+import (
+	"errors"
+	"fmt"
+	"game-mining-server/configs"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"unicode/utf8"
+)
+
+// maxPhotoCaptionLen telegram rejects photo captions longer than this
+const maxPhotoCaptionLen = 1024
+
+var (
+	ErrBroadcastCaptionTooLong = fmt.Errorf("photo caption is longer than %d characters", maxPhotoCaptionLen)
+	ErrBroadcastCursorMoved    = errors.New("broadcast cursor moved by other replica")
+)
+
+type Broadcast struct {
+	Id         int64  `gorm:"primaryKey;autoIncrement" json:"id"`            // broadcast id
+	CreatedAt  int64  `gorm:"autoCreateTime:milli" json:"createdAt"`         // created ts: 1670400478555
+	UpdatedAt  int64  `gorm:"autoUpdateTime:milli" json:"updatedAt"`         // updated ts: 1670400478555
+	CreatedBy  int64  `gorm:"type:bigint" json:"createdBy"`                  // admin user id who created the broadcast
+	Text       string `gorm:"type:text" json:"text"`                         // message text, or photo caption if photo is set
+	Photo      string `gorm:"type:varchar(1024)" json:"photo,omitempty"`     // photo url or telegram file id
+	ButtonText string `gorm:"type:varchar(255)" json:"buttonText,omitempty"` // inline button text
+	ButtonUrl  string `gorm:"type:varchar(1024)" json:"buttonUrl,omitempty"` // inline button url
+	Status     int    `gorm:"type:int" json:"status"`                        // broadcast status: 0: running, 1: paused, 2: cancelled, 3: finished
+	LastUid    int64  `gorm:"type:bigint" json:"lastUid"`                    // delivery cursor, users are delivered in ascending id order
+	Total      int64  `gorm:"type:bigint" json:"total"`                      // total users when broadcast created
+	Delivered  int64  `gorm:"type:bigint" json:"delivered"`                  // delivered user count
+	Blocked    int64  `gorm:"type:bigint" json:"blocked"`                    // user count who blocked the bot
+	Failed     int64  `gorm:"type:bigint" json:"failed"`                     // failed user count for other reasons
+}
+
+type BroadcastDelivery struct {
+	BroadcastId int64  `gorm:"primaryKey;type:bigint" json:"broadcastId"` // broadcast id
+	Uid         int64  `gorm:"primaryKey;type:bigint" json:"uid"`         // receiver user id
+	CreatedAt   int64  `gorm:"autoCreateTime:milli" json:"createdAt"`     // created ts: 1670400478555
+	Status      int    `gorm:"type:int" json:"status"`                    // delivery status: 1: delivered, 2: blocked, 3: failed
+	Error       string `gorm:"type:varchar(255)" json:"error,omitempty"`  // error message if not delivered
+}
+
+func (u *Broadcast) TableName() string {
+	return "broadcasts"
+}
+
+func (u *BroadcastDelivery) TableName() string {
+	return "broadcast_deliveries"
+}
+
+// Validate check a broadcast can be sent, text of a photo broadcast is its caption
+func (u *Broadcast) Validate() error {
+	if u.Photo != "" && utf8.RuneCountInString(u.Text) > maxPhotoCaptionLen {
+		return ErrBroadcastCaptionTooLong
+	}
+	return nil
+}
+
+// BroadcastCreate create a running broadcast to all users
+func (s *Service) BroadcastCreate(broadcast *Broadcast) error {
+	if e := broadcast.Validate(); e != nil {
+		return e
+	}
+	return s.DBInstance.Transaction(func(tx *gorm.DB) error {
+		if e0 := tx.Model(&User{}).Count(&broadcast.Total).Error; e0 != nil {
+			return e0
+		}
+		broadcast.Status = configs.BroadcastStatusRunning
+		return tx.Create(broadcast).Error
+	})
+}
+
+// BroadcastFindById find a broadcast by id
+func (s *Service) BroadcastFindById(id int64) (*Broadcast, error) {
+	var broadcast Broadcast
+	if e := s.DBInstance.Where("id = ?", id).First(&broadcast).Error; e != nil {
+		return nil, e
+	} else {
+		return &broadcast, nil
+	}
+}
+
+// BroadcastFindRunning find all running broadcasts
+func (s *Service) BroadcastFindRunning() ([]*Broadcast, error) {
+	var broadcasts []*Broadcast
+	if e := s.DBInstance.Where("status = ?", configs.BroadcastStatusRunning).Order("id asc").Find(&broadcasts).Error; e != nil {
+		return nil, e
+	} else {
+		return broadcasts, nil
+	}
+}
+
+// BroadcastUpdateStatus change broadcast status, only running/paused broadcast can be changed
+func (s *Service) BroadcastUpdateStatus(id int64, toStatus int) (*Broadcast, error) {
+	var broadcast Broadcast
+	e := s.DBInstance.Transaction(func(tx *gorm.DB) error {
+		if e0 := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(&broadcast).Error; e0 != nil {
+			return e0
+		}
+		if broadcast.Status != configs.BroadcastStatusRunning && broadcast.Status != configs.BroadcastStatusPaused {
+			return errors.New("broadcast already cancelled or finished")
+		}
+		broadcast.Status = toStatus
+		return tx.Model(&broadcast).Update("status", toStatus).Error
+	})
+	return &broadcast, e
+}
+
+// BroadcastSaveBatch save a batch of deliveries, move cursor from fromUid to lastUid and add counters of deliveries not
+// saved before, finish broadcast if it is the last batch. ErrBroadcastCursorMoved is returned and nothing is saved if
+// cursor is not at fromUid any more
+func (s *Service) BroadcastSaveBatch(id int64, fromUid int64, lastUid int64, deliveries []*BroadcastDelivery, finished bool) error {
+	return s.DBInstance.Transaction(func(tx *gorm.DB) error {
+		var broadcast Broadcast
+		if e0 := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(&broadcast).Error; e0 != nil {
+			return e0
+		}
+		if broadcast.LastUid != fromUid {
+			return ErrBroadcastCursorMoved
+		}
+
+		// only inserted deliveries are counted, a replayed delivery already exists
+		var delivered, blocked, failed int64
+		for _, delivery := range deliveries {
+			result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(delivery)
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				continue
+			}
+			switch delivery.Status {
+			case configs.BroadcastDeliveryDelivered:
+				delivered++
+			case configs.BroadcastDeliveryBlocked:
+				blocked++
+			default:
+				failed++
+			}
+		}
+		if e1 := tx.Model(&Broadcast{}).Where("id = ?", id).Updates(map[string]interface{}{
+			"last_uid":  lastUid,
+			"delivered": gorm.Expr("delivered + ?", delivered),
+			"blocked":   gorm.Expr("blocked + ?", blocked),
+			"failed":    gorm.Expr("failed + ?", failed),
+		}).Error; e1 != nil {
+			return e1
+		}
+		if !finished {
+			return nil
+		}
+		// a broadcast cancelled or paused during the last batch keeps its status
+		return tx.Model(&Broadcast{}).Where("id = ? AND status = ?", id, configs.BroadcastStatusRunning).
+			Update("status", configs.BroadcastStatusFinished).Error
+	})
+}
@@ -62,10 +62,43 @@ func (s *Service) CheckinGetLatestCheckin(db *gorm.DB, uid int64, basicConfig *c
 }
 
 func getBaseTimeTs(basicConfig *configs.BasicConfig) int64 {
+	return getBaseTimeTsAt(basicConfig, time.Now())
+}
+
+// getBaseTimeTsAt checkin base time at t, checkins created since base time are today's checkins
+func getBaseTimeTsAt(basicConfig *configs.BasicConfig, t time.Time) int64 {
 	if basicConfig.Env == configs.EnvPROD {
-		return utils.GetUTC0Ts()
+		return utils.GetUTC0TsAt(t)
+	} else {
+		return t.UnixMilli() - int64(basicConfig.CheckinBrokenSec*1000)
+	}
+}
+
+// CheckinFindStreaksAboutToBreak find users' latest checkin whose continuous sequence is still alive now
+// but will be broken within lead duration if they do not checkin, users are returned in ascending uid order
+func (s *Service) CheckinFindStreaksAboutToBreak(basicConfig *configs.BasicConfig, lead time.Duration, afterUid int64, limit int) ([]*Checkin, error) {
+	now := time.Now()
+	brokenMs := int64(basicConfig.CheckinBrokenSec * 1000)
+	baseTs := getBaseTimeTsAt(basicConfig, now)
+	// alive now: latest >= base(now) - broken, broken later: latest < base(now + lead) - broken, not checkin today: latest < base(now)
+	fromTs := baseTs - brokenMs
+	toTs := utils.Int64Min(baseTs, getBaseTimeTsAt(basicConfig, now.Add(lead))-brokenMs)
+	if fromTs >= toTs {
+		return nil, nil
+	}
+
+	// checkins before fromTs can not be an alive latest checkin, so only recent checkins are grouped (CREATED_UID index)
+	var checkins []*Checkin
+	latest := s.DBInstance.Model(&Checkin{}).Select("uid, MAX(created_at) AS latest_at").
+		Where("created_at >= ? AND uid > ?", fromTs, afterUid).
+		Group("uid").Having("latest_at < ?", toTs)
+	result := s.DBInstance.Model(&Checkin{}).Select("checkins.*").
+		Joins("JOIN (?) AS l ON l.uid = checkins.uid AND l.latest_at = checkins.created_at", latest).
+		Order("checkins.uid asc").Limit(limit).Find(&checkins)
+	if result.Error != nil {
+		return nil, result.Error
 	} else {
-		return time.Now().UnixMilli() - int64(basicConfig.CheckinBrokenSec*1000)
+		return checkins, nil
 	}
 }
 
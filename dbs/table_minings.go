@@ -0,0 +1,307 @@
+package dbs
+
+import (
+	"errors"
+	"game-mining-server/configs"
+	"game-mining-server/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+var (
+	ErrMiningNothingToClaim = errors.New("nothing mined to claim")
+	ErrUpgradeMaxLevel      = errors.New("upgrade is already at max level")
+	ErrUpgradeNotFound      = errors.New("upgrade not found")
+)
+
+// defaultMiningUpgrades upgrades sold in shop when none is configured
+var defaultMiningUpgrades = []*configs.MiningUpgradeConfig{
+	{Type: configs.MiningUpgradeRate, MaxLevel: 10, BaseCost: 100, CostGrowth: 1.6, StepValue: 5},
+	{Type: configs.MiningUpgradeCapacity, MaxLevel: 10, BaseCost: 100, CostGrowth: 1.6, StepValue: 40},
+	{Type: configs.MiningUpgradeAutoClaim, MaxLevel: 5, BaseCost: 500, CostGrowth: 2, StepValue: 3600},
+}
+
+type Mining struct {
+	Uid                  int64 `gorm:"primaryKey;type:bigint" json:"uid"`       // mining user id
+	CreatedAt            int64 `gorm:"autoCreateTime:milli" json:"createdAt"`   // created ts: 1670400478555, mining starts when created
+	UpdatedAt            int64 `gorm:"autoUpdateTime:milli" json:"-"`           // updated ts: 1670400478555
+	RatePerHour          int64 `gorm:"type:bigint" json:"ratePerHour"`          // points mined per hour
+	Capacity             int64 `gorm:"type:bigint" json:"capacity"`             // max unclaimed points, mining stops when storage is full
+	AutoClaimSec         int64 `gorm:"type:bigint" json:"autoClaimSec"`         // seconds mining goes on after storage is full, points are kept for next claim
+	RateLevel            int   `gorm:"type:int" json:"rateLevel"`               // rate upgrade level
+	CapacityLevel        int   `gorm:"type:int" json:"capacityLevel"`           // capacity upgrade level
+	AutoClaimLevel       int   `gorm:"type:int" json:"autoClaimLevel"`          // auto claim upgrade level
+	ExtraCapacity        int64 `gorm:"type:bigint" json:"extraCapacity"`        // capacity added by premium perks, included in capacity
+	LastClaimedAt        int64 `gorm:"type:bigint" json:"lastClaimedAt"`        // ts unclaimed points are mined since: 1670400478555
+	TotalMinedPointValue int64 `gorm:"type:bigint" json:"totalMinedPointValue"` // total claimed mining point value
+}
+
+// MiningStatus mining with points accrued until now
+type MiningStatus struct {
+	*Mining
+	Accrued int64 `json:"accrued"` // mined points waiting to be claimed
+	FullAt  int64 `json:"fullAt"`  // ts mining stops until claimed: 1670400478555
+}
+
+// MiningUpgrade an upgrade in shop with user's current level
+type MiningUpgrade struct {
+	Type      string `json:"type"`      // upgrade type: rate, capacity, autoClaim
+	Level     int    `json:"level"`     // user's current level
+	MaxLevel  int    `json:"maxLevel"`  // max level
+	Value     int64  `json:"value"`     // current value: points per hour for rate, points for capacity, seconds for autoClaim
+	NextValue int64  `json:"nextValue"` // value after next level, same as value at max level
+	NextCost  int64  `json:"nextCost"`  // points cost of next level, 0 at max level
+}
+
+func (u *Mining) TableName() string {
+	return "minings"
+}
+
+// maxAccrued points stored at most, auto claim keeps mining for autoClaimSec after storage is full
+func (u *Mining) maxAccrued() int64 {
+	return u.Capacity + u.RatePerHour*u.AutoClaimSec/3600
+}
+
+// Accrued points mined since last claim at nowMs, and the milliseconds they took
+func (u *Mining) Accrued(nowMs int64) (int64, int64) {
+	return utils.CalPointForMining(u.RatePerHour, u.maxAccrued(), nowMs-u.LastClaimedAt)
+}
+
+// Status mining status at nowMs
+func (u *Mining) Status(nowMs int64) *MiningStatus {
+	accrued, _ := u.Accrued(nowMs)
+	status := &MiningStatus{Mining: u, Accrued: accrued, FullAt: u.LastClaimedAt}
+	if u.RatePerHour > 0 {
+		status.FullAt = u.LastClaimedAt + (u.maxAccrued()*3600000+u.RatePerHour-1)/u.RatePerHour
+	}
+	return status
+}
+
+// level current level of an upgrade type, nil if type is unknown
+func (u *Mining) level(upgradeType string) *int {
+	switch upgradeType {
+	case configs.MiningUpgradeRate:
+		return &u.RateLevel
+	case configs.MiningUpgradeCapacity:
+		return &u.CapacityLevel
+	case configs.MiningUpgradeAutoClaim:
+		return &u.AutoClaimLevel
+	default:
+		return nil
+	}
+}
+
+// applyLevels recompute rate, capacity and auto claim duration from base values and upgrade levels
+func (u *Mining) applyLevels(config *configs.MiningConfig) {
+	u.RatePerHour, u.Capacity = miningBase(config)
+	u.Capacity += u.ExtraCapacity
+	u.AutoClaimSec = 0
+	for _, upgrade := range miningUpgrades(config) {
+		switch upgrade.Type {
+		case configs.MiningUpgradeRate:
+			u.RatePerHour += int64(u.RateLevel) * upgrade.StepValue
+		case configs.MiningUpgradeCapacity:
+			u.Capacity += int64(u.CapacityLevel) * upgrade.StepValue
+		case configs.MiningUpgradeAutoClaim:
+			u.AutoClaimSec += int64(u.AutoClaimLevel) * upgrade.StepValue
+		}
+	}
+}
+
+// applyExtraCapacity replace capacity added by perks with extraCapacity
+func (u *Mining) applyExtraCapacity(extraCapacity int64) {
+	u.Capacity = u.Capacity - u.ExtraCapacity + extraCapacity
+	u.ExtraCapacity = extraCapacity
+}
+
+// Upgrades all upgrades in shop with current levels, values and next level costs
+func (u *Mining) Upgrades(config *configs.MiningConfig) []*MiningUpgrade {
+	upgrades := miningUpgrades(config)
+	result := make([]*MiningUpgrade, 0, len(upgrades))
+	for _, upgrade := range upgrades {
+		if u.level(upgrade.Type) == nil {
+			continue
+		}
+		level := *u.level(upgrade.Type)
+		item := &MiningUpgrade{Type: upgrade.Type, Level: level, MaxLevel: upgrade.MaxLevel}
+		switch upgrade.Type {
+		case configs.MiningUpgradeRate:
+			item.Value = u.RatePerHour
+		case configs.MiningUpgradeCapacity:
+			item.Value = u.Capacity - u.ExtraCapacity
+		case configs.MiningUpgradeAutoClaim:
+			item.Value = u.AutoClaimSec
+		}
+		item.NextValue = item.Value
+		if level < upgrade.MaxLevel {
+			item.NextValue = item.Value + upgrade.StepValue
+			item.NextCost = utils.CalUpgradeCost(upgrade.BaseCost, upgrade.CostGrowth, level+1)
+		}
+		result = append(result, item)
+	}
+	return result
+}
+
+// miningBase base rate and capacity before upgrades
+func miningBase(config *configs.MiningConfig) (int64, int64) {
+	rate, capacity := configs.MiningBaseRatePerHour, configs.MiningBaseCapacity
+	if config != nil && config.BaseRatePerHour > 0 {
+		rate = config.BaseRatePerHour
+	}
+	if config != nil && config.BaseCapacity > 0 {
+		capacity = config.BaseCapacity
+	}
+	return rate, capacity
+}
+
+func miningUpgrades(config *configs.MiningConfig) []*configs.MiningUpgradeConfig {
+	if config != nil && len(config.Upgrades) > 0 {
+		return config.Upgrades
+	}
+	return defaultMiningUpgrades
+}
+
+func newMining(uid int64, extraCapacity int64, config *configs.MiningConfig) *Mining {
+	rate, capacity := miningBase(config)
+	return &Mining{
+		Uid:           uid,
+		RatePerHour:   rate,
+		Capacity:      capacity + extraCapacity,
+		ExtraCapacity: extraCapacity,
+		LastClaimedAt: time.Now().UnixMilli(),
+	}
+}
+
+// MiningFindOrCreate find a user's mining, start mining with base rate and capacity if not exists.
+// Capacity added by perks is changed on next claim or upgrade, after points mined at the old capacity are settled
+func (s *Service) MiningFindOrCreate(uid int64, extraCapacity int64, config *configs.MiningConfig) (*Mining, error) {
+	var mining Mining
+	if e := s.DBInstance.Where(Mining{Uid: uid}).Attrs(newMining(uid, extraCapacity, config)).FirstOrCreate(&mining).Error; e != nil {
+		return nil, e
+	} else {
+		return &mining, nil
+	}
+}
+
+// findOrCreateMiningForUpdate find a user's mining and lock it until transaction ends, if not exists, start mining
+func findOrCreateMiningForUpdate(db *gorm.DB, uid int64, extraCapacity int64, config *configs.MiningConfig, mining *Mining) error {
+	if e0 := db.Where(Mining{Uid: uid}).Attrs(newMining(uid, extraCapacity, config)).FirstOrCreate(mining).Error; e0 != nil {
+		return e0
+	}
+	return db.Clauses(clause.Locking{Strength: "UPDATE"}).Where("uid = ?", uid).First(mining).Error
+}
+
+// updateExtraCapacity settle points mined at the old capacity until nowMs, then change capacity added by perks to
+// extraCapacity, so a new capacity never applies to time already mined. Return settled points
+func (s *Service) updateExtraCapacity(db *gorm.DB, mining *Mining, extraCapacity int64, nowMs int64) (int64, *Point, error) {
+	if mining.ExtraCapacity == extraCapacity {
+		return 0, nil, nil
+	}
+	mined, point, e0 := s.settleMining(db, mining, nowMs)
+	if e0 != nil {
+		return 0, nil, e0
+	}
+	mining.applyExtraCapacity(extraCapacity)
+	if e1 := db.Save(mining).Error; e1 != nil {
+		return 0, nil, e1
+	}
+	return mined, point, nil
+}
+
+// settleMining credit points mined until nowMs to user's point, time not used by the credited points keeps mining.
+// A full storage uses all the time since last claim, so mining restarts from nowMs
+func (s *Service) settleMining(db *gorm.DB, mining *Mining, nowMs int64) (int64, *Point, error) {
+	mined, usedMs := mining.Accrued(nowMs)
+	if mined <= 0 {
+		return 0, nil, nil
+	}
+	mining.LastClaimedAt = mining.LastClaimedAt + usedMs
+	mining.TotalMinedPointValue = mining.TotalMinedPointValue + mined
+	if e0 := db.Save(mining).Error; e0 != nil {
+		return 0, nil, e0
+	}
+	point, e1 := s.PointClaimForMining(db, mining.Uid, mined)
+	return mined, point, e1
+}
+
+// MiningClaim credit points mined since last claim to user's point
+func (s *Service) MiningClaim(uid int64, extraCapacity int64, config *configs.MiningConfig) (*MiningStatus, *Point, error) {
+	var mining Mining
+	var point *Point
+	var claimedAt int64
+	e := s.DBInstance.Transaction(func(tx *gorm.DB) error {
+		if e0 := findOrCreateMiningForUpdate(tx, uid, extraCapacity, config, &mining); e0 != nil {
+			return e0
+		}
+		claimedAt = time.Now().UnixMilli()
+		settled, settledPoint, e1 := s.updateExtraCapacity(tx, &mining, extraCapacity, claimedAt)
+		if e1 != nil {
+			return e1
+		}
+		mined, _point, e2 := s.settleMining(tx, &mining, claimedAt)
+		if e2 != nil {
+			return e2
+		}
+		if settled+mined <= 0 {
+			return ErrMiningNothingToClaim
+		}
+		point = settledPoint
+		if _point != nil {
+			point = _point
+		}
+		return nil
+	})
+	if e != nil {
+		return nil, nil, e
+	}
+	return mining.Status(claimedAt), point, nil
+}
+
+// MiningBuyUpgrade buy next level of an upgrade with points. Points mined so far are claimed first at the old rate,
+// then the level cost is subtracted from user's total point value
+func (s *Service) MiningBuyUpgrade(uid int64, upgradeType string, extraCapacity int64, config *configs.MiningConfig) (*MiningStatus, *Point, error) {
+	var upgrade *configs.MiningUpgradeConfig
+	for _, item := range miningUpgrades(config) {
+		if item.Type == upgradeType {
+			upgrade = item
+		}
+	}
+	if upgrade == nil || (&Mining{}).level(upgradeType) == nil {
+		return nil, nil, ErrUpgradeNotFound
+	}
+
+	var mining Mining
+	var point Point
+	var boughtAt int64
+	e := s.DBInstance.Transaction(func(tx *gorm.DB) error {
+		if e0 := findOrCreateMiningForUpdate(tx, uid, extraCapacity, config, &mining); e0 != nil {
+			return e0
+		}
+		level := mining.level(upgradeType)
+		if *level >= upgrade.MaxLevel {
+			return ErrUpgradeMaxLevel
+		}
+		boughtAt = time.Now().UnixMilli()
+		if _, _, e1 := s.updateExtraCapacity(tx, &mining, extraCapacity, boughtAt); e1 != nil {
+			return e1
+		}
+		if _, _, e2 := s.settleMining(tx, &mining, boughtAt); e2 != nil {
+			return e2
+		}
+		if e3 := s.PointSpend(tx, uid, utils.CalUpgradeCost(upgrade.BaseCost, upgrade.CostGrowth, *level+1)); e3 != nil {
+			return e3
+		}
+
+		*level++
+		mining.applyLevels(config)
+		if e4 := tx.Save(&mining).Error; e4 != nil {
+			return e4
+		}
+		return tx.Where("uid = ?", uid).First(&point).Error
+	})
+	if e != nil {
+		return nil, nil, e
+	}
+	return mining.Status(boughtAt), &point, nil
+}
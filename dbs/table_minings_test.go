@@ -0,0 +1,49 @@
+package dbs
+
+import (
+	"game-mining-server/configs"
+	"testing"
+)
+
+func TestMiningAccrued(t *testing.T) {
+	cases := []struct {
+		name       string
+		mining     Mining
+		elapsedMs  int64
+		wantMined  int64
+		wantUsedMs int64
+	}{
+		{"mining", Mining{RatePerHour: 20, Capacity: 80}, 90 * 60000, 30, 90 * 60000},
+		{"keeps leftover time", Mining{RatePerHour: 20, Capacity: 80}, 90*60000 + 1000, 30, 90 * 60000},
+		{"full storage", Mining{RatePerHour: 20, Capacity: 80}, 10 * 3600000, 80, 10 * 3600000},
+		{"auto claim mines after storage is full", Mining{RatePerHour: 20, Capacity: 80, AutoClaimSec: 3600}, 10 * 3600000, 100, 10 * 3600000},
+	}
+	for _, c := range cases {
+		c.mining.LastClaimedAt = testNowMs - c.elapsedMs
+		mined, usedMs := c.mining.Accrued(testNowMs)
+		if mined != c.wantMined || usedMs != c.wantUsedMs {
+			t.Errorf("%s: want %d points in %dms, got %d in %dms", c.name, c.wantMined, c.wantUsedMs, mined, usedMs)
+		}
+	}
+}
+
+func TestMiningStatusFullAt(t *testing.T) {
+	mining := &Mining{RatePerHour: 20, Capacity: 80, AutoClaimSec: 1800, LastClaimedAt: testNowMs}
+	status := mining.Status(testNowMs + 3600000)
+	if status.Accrued != 20 || status.FullAt != testNowMs+4*3600000+1800000 {
+		t.Errorf("want 20 accrued and full after 4.5h, got %d and %d", status.Accrued, status.FullAt-testNowMs)
+	}
+}
+
+func TestMiningApplyLevels(t *testing.T) {
+	mining := &Mining{RateLevel: 2, CapacityLevel: 1, AutoClaimLevel: 3, ExtraCapacity: 40}
+	mining.applyLevels(nil)
+	if mining.RatePerHour != configs.MiningBaseRatePerHour+10 || mining.Capacity != configs.MiningBaseCapacity+40+40 || mining.AutoClaimSec != 3*3600 {
+		t.Errorf("bad levels applied: %+v", mining)
+	}
+
+	mining.applyExtraCapacity(0)
+	if mining.Capacity != configs.MiningBaseCapacity+40 || mining.ExtraCapacity != 0 {
+		t.Errorf("want perk capacity removed, got %+v", mining)
+	}
+}
@@ -24,6 +24,16 @@ func (u *Moment) TableName() string {
 	return "moments"
 }
 
+// MomentFindById find a moment by id
+func (s *Service) MomentFindById(id int64) (*Moment, error) {
+	var moment Moment
+	if e := s.DBInstance.Where("id = ?", id).First(&moment).Error; e != nil {
+		return nil, e
+	} else {
+		return &moment, nil
+	}
+}
+
 func (s *Service) CreateMoment(params entities.CreateMomentParam) (int64, error) {
 	moment := Moment{
 		UserId:   params.UserId,
@@ -0,0 +1,59 @@
+package dbs
+
+import (
+	"errors"
+	"gorm.io/gorm"
+)
+
+type NotificationPref struct {
+	Uid             int64 `gorm:"primaryKey;type:bigint" json:"uid"`     // user id
+	CreatedAt       int64 `gorm:"autoCreateTime:milli" json:"createdAt"` // created ts: 1670400478555
+	UpdatedAt       int64 `gorm:"autoUpdateTime:milli" json:"-"`         // updated ts: 1670400478555
+	MomentLike      bool  `gorm:"type:bool" json:"momentLike"`           // notify when someone likes user's moment
+	MomentComment   bool  `gorm:"type:bool" json:"momentComment"`        // notify when someone comments on user's moment
+	MomentReward    bool  `gorm:"type:bool" json:"momentReward"`         // notify when someone tips user's moment
+	CheckinReminder bool  `gorm:"type:bool" json:"checkinReminder"`      // notify when a daily checkin is waiting
+}
+
+func (u *NotificationPref) TableName() string {
+	return "notification_prefs"
+}
+
+// defaultNotificationPref all notifications are enabled by default
+func defaultNotificationPref(uid int64) *NotificationPref {
+	return &NotificationPref{
+		Uid:             uid,
+		MomentLike:      true,
+		MomentComment:   true,
+		MomentReward:    true,
+		CheckinReminder: true,
+	}
+}
+
+// NotificationPrefFindByUid find a user's notification preferences, return default preferences if not set
+func (s *Service) NotificationPrefFindByUid(uid int64) (*NotificationPref, error) {
+	var pref NotificationPref
+	if e := s.DBInstance.Where("uid = ?", uid).First(&pref).Error; e != nil {
+		if errors.Is(e, gorm.ErrRecordNotFound) {
+			return defaultNotificationPref(uid), nil
+		}
+		return nil, e
+	} else {
+		return &pref, nil
+	}
+}
+
+// NotificationPrefUpdate update a user's notification preferences, create with default preferences if not exists
+func (s *Service) NotificationPrefUpdate(uid int64, updated map[string]interface{}) (*NotificationPref, error) {
+	var pref NotificationPref
+	e := s.DBInstance.Transaction(func(tx *gorm.DB) error {
+		if e0 := tx.Where(NotificationPref{Uid: uid}).Attrs(defaultNotificationPref(uid)).FirstOrCreate(&pref).Error; e0 != nil {
+			return e0
+		}
+		if len(updated) == 0 {
+			return nil
+		}
+		return tx.Model(&pref).Updates(updated).Error
+	})
+	return &pref, e
+}
@@ -1,21 +1,30 @@
 package dbs
 
 import (
+	"errors"
 	"fmt"
+	"game-mining-server/configs"
 	"game-mining-server/entities"
 	"game-mining-server/utils"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+var ErrNotEnoughPoints = errors.New("not enough points")
+
 type Point struct {
-	Uid                   int64 `gorm:"primaryKey;type:bigint" json:"uid"`        // point user id, unique
-	CreatedAt             int64 `gorm:"autoCreateTime:milli" json:"createdAt"`    // created ts: 1670400478555
-	UpdatedAt             int64 `gorm:"autoUpdateTime:milli" json:"-"`            // updated ts: 1670400478555
-	LastClaimedPointValue int64 `gorm:"type:bigint" json:"lastClaimedPointValue"` // last time claimed point value
-	TotalWalletPointValue int64 `gorm:"type:bigint" json:"totalWalletPointValue"` // total wallet related point value, no need record for last time
-	LastInvitePointLevel  int64 `gorm:"type:bigint" json:"lastInvitePointLevel"`  // last time user claimed for invite level
-	TotalInvitePointValue int64 `gorm:"type:bigint" json:"totalInvitePointValue"` // total invite point value
-	TotalPointValue       int64 `gorm:"type:bigint" json:"totalPointValue"`       // total point value, totalPointValue = claimedPointValue * count + totalWalletPointValue + totalInvitePointValue
+	Uid                        int64   `gorm:"primaryKey;type:bigint" json:"uid"`             // point user id, unique
+	CreatedAt                  int64   `gorm:"autoCreateTime:milli" json:"createdAt"`         // created ts: 1670400478555
+	UpdatedAt                  int64   `gorm:"autoUpdateTime:milli" json:"-"`                 // updated ts: 1670400478555
+	LastClaimedPointValue      int64   `gorm:"type:bigint" json:"lastClaimedPointValue"`      // last time claimed point value
+	TotalWalletPointValue      int64   `gorm:"type:bigint" json:"totalWalletPointValue"`      // total wallet related point value, no need record for last time
+	LastInvitePointLevel       int64   `gorm:"type:bigint" json:"lastInvitePointLevel"`       // last time user claimed for invite level
+	TotalInvitePointValue      int64   `gorm:"type:bigint" json:"totalInvitePointValue"`      // total invite point value
+	TotalMiningPointValue      int64   `gorm:"type:bigint" json:"totalMiningPointValue"`      // total mined point value
+	TotalTapPointValue         int64   `gorm:"type:bigint" json:"totalTapPointValue"`         // total tap point value
+	TotalAchievementPointValue int64   `gorm:"type:bigint" json:"totalAchievementPointValue"` // total point value awarded with badges
+	TotalPointValue            int64   `gorm:"type:bigint" json:"totalPointValue"`            // total point value, totalPointValue = claimedPointValue * count + totalWalletPointValue + totalInvitePointValue + totalMiningPointValue + totalTapPointValue + totalAchievementPointValue
+	Multiplier                 float64 `gorm:"-" json:"multiplier,omitempty"`                 // booster multiplier applied to the points just claimed, empty if not boosted
 }
 
 var pointWithUserQueryFields = `
@@ -34,6 +43,11 @@ func (u *Point) TableName() string {
 	return "points"
 }
 
+// findOrCreatePointForUpdate find a user's point and lock it until transaction ends, if not exists, create it
+func findOrCreatePointForUpdate(db *gorm.DB, uid int64, point *Point) error {
+	return db.Clauses(clause.Locking{Strength: "UPDATE"}).Where(Point{Uid: uid}).Attrs(&Point{Uid: uid}).FirstOrCreate(point).Error
+}
+
 // PointFindByUid find a user's point by uid
 func (s *Service) PointFindByUid(uid int64) (*Point, error) {
 	var point Point
@@ -44,38 +58,104 @@ func (s *Service) PointFindByUid(uid int64) (*Point, error) {
 	}
 }
 
-// PointClaimTask Claim a point value for specified user, if not exists, create it
+// PointClaimTask Claim a point value multiplied by active booster for specified user, if not exists, create it
 func (s *Service) PointClaimTask(db *gorm.DB, uid int64, claimed int64) (*Point, error) {
 	var point Point
-	if e0 := db.Where(Point{Uid: uid}).Attrs(&Point{Uid: uid}).FirstOrCreate(&point).Error; e0 != nil {
+	if e0 := findOrCreatePointForUpdate(db, uid, &point); e0 != nil {
 		return nil, e0
 	}
+	claimed, multiplier, e1 := s.boostPoint(db, uid, claimed)
+	if e1 != nil {
+		return nil, e1
+	}
 	point.LastClaimedPointValue = claimed
 	point.TotalPointValue = point.TotalPointValue + claimed
 	if e5 := db.Save(&point).Error; e5 != nil {
 		return nil, e5
 	} else {
+		point.Multiplier = boostedMultiplier(multiplier)
 		return &point, nil
 	}
 }
 
-func (s *Service) PointClaimForWallet(uid int64, walletPoint int64) (*Point, error) {
+// boostedMultiplier multiplier shown in response, 0 if points are not boosted
+func boostedMultiplier(multiplier float64) float64 {
+	if multiplier > 1 {
+		return multiplier
+	}
+	return 0
+}
+
+func (s *Service) PointClaimForWallet(uid int64, walletPoint int64, config *configs.Config) (*Point, error) {
 	var point Point
 	e := s.DBInstance.Transaction(func(tx *gorm.DB) error {
-		if e0 := tx.Where(Point{Uid: uid}).Attrs(&Point{Uid: uid}).FirstOrCreate(&point).Error; e0 != nil {
+		if e0 := findOrCreatePointForUpdate(tx, uid, &point); e0 != nil {
 			return e0
 		}
+		walletPoint, multiplier, e1 := s.boostPoint(tx, uid, walletPoint)
+		if e1 != nil {
+			return e1
+		}
+		point.Multiplier = boostedMultiplier(multiplier)
 		point.TotalWalletPointValue = point.TotalWalletPointValue + walletPoint
 		point.TotalPointValue = point.TotalPointValue + walletPoint
-		return tx.Save(&point).Error
+		if e2 := tx.Save(&point).Error; e2 != nil {
+			return e2
+		}
+		return s.grantTaskBoosters(tx, uid, configs.TaskTypeWalletSendTx, config)
 	})
 	return &point, e
 }
 
-func (s *Service) PointClaimForInvite(uid int64, level int64) (*Point, error) {
+// PointClaimForMining add mined points to a user's point, if not exists, create it
+func (s *Service) PointClaimForMining(db *gorm.DB, uid int64, mined int64) (*Point, error) {
+	var point Point
+	if e0 := findOrCreatePointForUpdate(db, uid, &point); e0 != nil {
+		return nil, e0
+	}
+	point.TotalMiningPointValue = point.TotalMiningPointValue + mined
+	point.TotalPointValue = point.TotalPointValue + mined
+	if e1 := db.Save(&point).Error; e1 != nil {
+		return nil, e1
+	} else {
+		return &point, nil
+	}
+}
+
+// PointClaimForTap add tap points to a user's point, if not exists, create it
+func (s *Service) PointClaimForTap(db *gorm.DB, uid int64, tapPoint int64) (*Point, error) {
+	var point Point
+	if e0 := findOrCreatePointForUpdate(db, uid, &point); e0 != nil {
+		return nil, e0
+	}
+	point.TotalTapPointValue = point.TotalTapPointValue + tapPoint
+	point.TotalPointValue = point.TotalPointValue + tapPoint
+	if e1 := db.Save(&point).Error; e1 != nil {
+		return nil, e1
+	} else {
+		return &point, nil
+	}
+}
+
+// PointClaimForAchievement add points awarded with a badge to a user's point, if not exists, create it
+func (s *Service) PointClaimForAchievement(db *gorm.DB, uid int64, rewardPoint int64) (*Point, error) {
+	var point Point
+	if e0 := findOrCreatePointForUpdate(db, uid, &point); e0 != nil {
+		return nil, e0
+	}
+	point.TotalAchievementPointValue = point.TotalAchievementPointValue + rewardPoint
+	point.TotalPointValue = point.TotalPointValue + rewardPoint
+	if e1 := db.Save(&point).Error; e1 != nil {
+		return nil, e1
+	} else {
+		return &point, nil
+	}
+}
+
+func (s *Service) PointClaimForInvite(uid int64, level int64, config *configs.Config) (*Point, error) {
 	var point Point
 	e := s.DBInstance.Transaction(func(tx *gorm.DB) error {
-		if e0 := tx.Where(Point{Uid: uid}).Attrs(&Point{Uid: uid}).FirstOrCreate(&point).Error; e0 != nil {
+		if e0 := findOrCreatePointForUpdate(tx, uid, &point); e0 != nil {
 			return e0
 		}
 
@@ -93,15 +173,36 @@ func (s *Service) PointClaimForInvite(uid int64, level int64) (*Point, error) {
 		if invitePoint <= 0 {
 			return fmt.Errorf("not enough invites: %d or not supported level: %d", inviteCount, level)
 		}
+		invitePoint, multiplier, e2 := s.boostPoint(tx, uid, invitePoint)
+		if e2 != nil {
+			return e2
+		}
 
+		point.Multiplier = boostedMultiplier(multiplier)
 		point.LastInvitePointLevel = level
 		point.TotalInvitePointValue = point.TotalInvitePointValue + invitePoint
 		point.TotalPointValue = point.TotalPointValue + invitePoint
-		return tx.Save(&point).Error
+		if e3 := tx.Save(&point).Error; e3 != nil {
+			return e3
+		}
+		return s.grantTaskBoosters(tx, uid, configs.TaskTypeInviteFriends, config)
 	})
 	return &point, e
 }
 
+// PointSpend subtract cost from a user's total point value, fail with ErrNotEnoughPoints if the user can not afford it
+func (s *Service) PointSpend(db *gorm.DB, uid int64, cost int64) error {
+	result := db.Model(&Point{}).Where("uid = ? AND total_point_value >= ?", uid, cost).
+		Update("total_point_value", gorm.Expr("total_point_value - ?", cost))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotEnoughPoints
+	}
+	return nil
+}
+
 func (s *Service) PointGetLeaderBoardWithUser(params *entities.LeaderBoardParam) ([]*PointWithUser, int64, error) {
 	var points []*PointWithUser
 	var total int64
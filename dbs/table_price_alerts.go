@@ -0,0 +1,130 @@
+package dbs
+
+import (
+	"game-mining-server/configs"
+	"gorm.io/gorm"
+	"time"
+)
+
+type PriceAlert struct {
+	Id           int64   `gorm:"primaryKey;autoIncrement" json:"id"`    // alert id
+	CreatedAt    int64   `gorm:"autoCreateTime:milli" json:"createdAt"` // created ts: 1670400478555
+	UpdatedAt    int64   `gorm:"autoUpdateTime:milli" json:"updatedAt"` // updated ts: 1670400478555
+	Uid          int64   `gorm:"type:bigint" json:"uid"`                // owner user id
+	CoinSymbol   string  `gorm:"type:varchar(32)" json:"coinSymbol"`    // coin symbol: BTC
+	FiatSymbol   string  `gorm:"type:varchar(8)" json:"fiatSymbol"`     // fiat of target price: USD
+	Direction    int     `gorm:"type:int" json:"direction"`             // 1: rises above target, 2: falls below target
+	TargetPrice  float64 `gorm:"type:double" json:"targetPrice"`        // target price in fiat
+	Recurring    bool    `gorm:"type:bool" json:"recurring"`            // recurring alert is re-armed when price crosses back, one-shot alert stops after triggered
+	Armed        bool    `gorm:"type:bool" json:"armed"`                // whether alert triggers on next crossing
+	Status       int     `gorm:"type:int" json:"status"`                // alert status: 0: active, 1: triggered
+	TriggerCount int64   `gorm:"type:bigint" json:"triggerCount"`       // times the alert triggered
+	TriggeredAt  int64   `gorm:"type:bigint" json:"triggeredAt"`        // last triggered ts: 1670400478555, 0 if never triggered
+}
+
+func (u *PriceAlert) TableName() string {
+	return "price_alerts"
+}
+
+// IsReached whether a fiat price reaches the alert target
+func (u *PriceAlert) IsReached(price float64) bool {
+	if u.Direction == configs.PriceAlertDirectionBelow {
+		return price <= u.TargetPrice
+	}
+	return price >= u.TargetPrice
+}
+
+// Arm arm the alert only if a fiat price is on the opposite side of the target, otherwise it is armed once price
+// crosses back
+func (u *PriceAlert) Arm(price float64) {
+	u.Armed = !u.IsReached(price)
+}
+
+// PriceAlertCreate create an active alert, armed by Arm
+func (s *Service) PriceAlertCreate(alert *PriceAlert) error {
+	alert.Status = configs.PriceAlertStatusActive
+	return s.DBInstance.Create(alert).Error
+}
+
+// PriceAlertCountByUid count alerts of a user
+func (s *Service) PriceAlertCountByUid(uid int64) (int64, error) {
+	var count int64
+	e := s.DBInstance.Model(&PriceAlert{}).Where("uid = ?", uid).Count(&count).Error
+	return count, e
+}
+
+// PriceAlertFindByUid find all alerts of a user, newest first
+func (s *Service) PriceAlertFindByUid(uid int64) ([]*PriceAlert, error) {
+	var alerts []*PriceAlert
+	if e := s.DBInstance.Where("uid = ?", uid).Order("id desc").Find(&alerts).Error; e != nil {
+		return nil, e
+	} else {
+		return alerts, nil
+	}
+}
+
+// PriceAlertFindById find an alert of a user by id
+func (s *Service) PriceAlertFindById(uid int64, id int64) (*PriceAlert, error) {
+	var alert PriceAlert
+	if e := s.DBInstance.Where("id = ? AND uid = ?", id, uid).First(&alert).Error; e != nil {
+		return nil, e
+	} else {
+		return &alert, nil
+	}
+}
+
+// PriceAlertUpdate update an alert of a user, the alert is activated again
+func (s *Service) PriceAlertUpdate(uid int64, id int64, updated map[string]interface{}) (*PriceAlert, error) {
+	updated["status"] = configs.PriceAlertStatusActive
+	if e0 := s.DBInstance.Model(&PriceAlert{}).Where("id = ? AND uid = ?", id, uid).Updates(updated).Error; e0 != nil {
+		return nil, e0
+	}
+	return s.PriceAlertFindById(uid, id)
+}
+
+// PriceAlertDelete delete an alert of a user, return false if not found
+func (s *Service) PriceAlertDelete(uid int64, id int64) (bool, error) {
+	result := s.DBInstance.Where("id = ? AND uid = ?", id, uid).Delete(&PriceAlert{})
+	return result.RowsAffected > 0, result.Error
+}
+
+// PriceAlertFindActiveSymbols find distinct coin symbols of all active alerts
+func (s *Service) PriceAlertFindActiveSymbols() ([]string, error) {
+	var symbols []string
+	e := s.DBInstance.Model(&PriceAlert{}).Where("status = ?", configs.PriceAlertStatusActive).Distinct().Pluck("coin_symbol", &symbols).Error
+	return symbols, e
+}
+
+// PriceAlertFindActiveBySymbols find active alerts of coin symbols
+func (s *Service) PriceAlertFindActiveBySymbols(symbols []string) ([]*PriceAlert, error) {
+	var alerts []*PriceAlert
+	if e := s.DBInstance.Where("status = ? AND coin_symbol IN ?", configs.PriceAlertStatusActive, symbols).Find(&alerts).Error; e != nil {
+		return nil, e
+	} else {
+		return alerts, nil
+	}
+}
+
+// PriceAlertTrigger disarm an armed alert, one-shot alert is also marked triggered.
+// Return false if the alert was disarmed by another replica or changed by its owner meanwhile.
+func (s *Service) PriceAlertTrigger(alert *PriceAlert) (bool, error) {
+	updated := map[string]interface{}{
+		"armed":         false,
+		"trigger_count": gorm.Expr("trigger_count + 1"),
+		"triggered_at":  time.Now().UnixMilli(),
+	}
+	if !alert.Recurring {
+		updated["status"] = configs.PriceAlertStatusTriggered
+	}
+	result := s.DBInstance.Model(&PriceAlert{}).
+		Where("id = ? AND armed = ? AND updated_at = ?", alert.Id, true, alert.UpdatedAt).
+		Updates(updated)
+	return result.RowsAffected > 0, result.Error
+}
+
+// PriceAlertRearm arm a disarmed active alert again after price crossed back
+func (s *Service) PriceAlertRearm(alert *PriceAlert) error {
+	return s.DBInstance.Model(&PriceAlert{}).
+		Where("id = ? AND armed = ? AND status = ?", alert.Id, false, configs.PriceAlertStatusActive).
+		Update("armed", true).Error
+}
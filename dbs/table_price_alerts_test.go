@@ -0,0 +1,29 @@
+package dbs
+
+import (
+	"game-mining-server/configs"
+	"testing"
+)
+
+func TestPriceAlertArm(t *testing.T) {
+	cases := []struct {
+		name      string
+		direction int
+		price     float64
+		wantArmed bool
+	}{
+		{"above, price below target", configs.PriceAlertDirectionAbove, 99, true},
+		{"above, price at target", configs.PriceAlertDirectionAbove, 100, false},
+		{"above, price past target", configs.PriceAlertDirectionAbove, 101, false},
+		{"below, price above target", configs.PriceAlertDirectionBelow, 101, true},
+		{"below, price at target", configs.PriceAlertDirectionBelow, 100, false},
+		{"below, price past target", configs.PriceAlertDirectionBelow, 99, false},
+	}
+	for _, c := range cases {
+		alert := &PriceAlert{Direction: c.direction, TargetPrice: 100, Armed: !c.wantArmed}
+		alert.Arm(c.price)
+		if alert.Armed != c.wantArmed {
+			t.Errorf("%s: want armed %v, got %v", c.name, c.wantArmed, alert.Armed)
+		}
+	}
+}
@@ -0,0 +1,228 @@
+package dbs
+
+import (
+	"errors"
+	"fmt"
+	"game-mining-server/configs"
+	"game-mining-server/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+const (
+	tapMaxClockSkewMs = int64(30 * 1000)      // client clock may run ahead of server by this much
+	tapMaxBatchAgeMs  = int64(10 * 60 * 1000) // batches started earlier than this are too old to submit
+)
+
+var (
+	ErrTapRejected = errors.New("tap batch rejected")
+	ErrTapFlagged  = errors.New("user is flagged for impossible taps")
+)
+
+type Tap struct {
+	Uid              int64  `gorm:"primaryKey;type:bigint" json:"uid"`                   // tap user id
+	CreatedAt        int64  `gorm:"autoCreateTime:milli" json:"createdAt"`               // created ts: 1670400478555
+	UpdatedAt        int64  `gorm:"autoUpdateTime:milli" json:"-"`                       // updated ts: 1670400478555
+	Energy           int64  `gorm:"type:bigint" json:"energy"`                           // energy left, each tap costs 1 energy
+	EnergyUpdatedAt  int64  `gorm:"type:bigint" json:"energyUpdatedAt"`                  // ts energy is regenerated since: 1670400478555
+	LastEndedAt      int64  `gorm:"type:bigint" json:"lastEndedAt"`                      // client ts of last tap in last accepted batch, batches must not overlap
+	TotalTaps        int64  `gorm:"type:bigint" json:"totalTaps"`                        // total credited taps
+	TotalPointValue  int64  `gorm:"type:bigint" json:"totalPointValue"`                  // total point value credited by taps
+	AcceptedBatches  int64  `gorm:"type:bigint" json:"acceptedBatches"`                  // accepted batch count
+	RejectedBatches  int64  `gorm:"type:bigint" json:"rejectedBatches"`                  // rejected batch count
+	WindowRejects    int64  `gorm:"type:bigint" json:"windowRejects"`                    // rejected batch count in current flag window
+	WindowStartedAt  int64  `gorm:"type:bigint" json:"windowStartedAt"`                  // ts of first reject in current flag window: 1670400478555
+	ClampedTaps      int64  `gorm:"type:bigint" json:"clampedTaps"`                      // taps of accepted batches not credited for lack of energy
+	Flagged          bool   `gorm:"type:bool" json:"flagged"`                            // flagged user can not tap any more
+	LastRejectReason string `gorm:"type:varchar(255)" json:"lastRejectReason,omitempty"` // reason of last rejected batch
+}
+
+// TapStatus tap state with energy regenerated until now
+type TapStatus struct {
+	*Tap
+	MaxEnergy         int64 `json:"maxEnergy"`
+	EnergyRegenPerSec int64 `json:"energyRegenPerSec"`
+	PointsPerTap      int64 `json:"pointsPerTap"`
+}
+
+func (u *Tap) TableName() string {
+	return "taps"
+}
+
+// tapSettings tap config with defaults for zero values
+func tapSettings(config *configs.TapConfig) configs.TapConfig {
+	settings := configs.TapConfig{
+		MaxEnergy:         configs.TapMaxEnergy,
+		EnergyRegenPerSec: configs.TapEnergyRegenPerSec,
+		PointsPerTap:      configs.TapPointsPerTap,
+		MaxTapsPerSec:     configs.TapMaxTapsPerSec,
+		FlagAfterRejects:  configs.TapFlagAfterRejects,
+		FlagWindowSec:     configs.TapFlagWindowSec,
+	}
+	if config == nil {
+		return settings
+	}
+	if config.MaxEnergy > 0 {
+		settings.MaxEnergy = config.MaxEnergy
+	}
+	if config.EnergyRegenPerSec > 0 {
+		settings.EnergyRegenPerSec = config.EnergyRegenPerSec
+	}
+	if config.PointsPerTap > 0 {
+		settings.PointsPerTap = config.PointsPerTap
+	}
+	if config.MaxTapsPerSec > 0 {
+		settings.MaxTapsPerSec = config.MaxTapsPerSec
+	}
+	if config.FlagAfterRejects > 0 {
+		settings.FlagAfterRejects = config.FlagAfterRejects
+	}
+	if config.FlagWindowSec > 0 {
+		settings.FlagWindowSec = config.FlagWindowSec
+	}
+	return settings
+}
+
+// regen regenerate energy until nowMs, time not used by regenerated energy is kept unless the pool is full
+func (u *Tap) regen(nowMs int64, settings *configs.TapConfig) {
+	if u.Energy >= settings.MaxEnergy {
+		u.Energy, u.EnergyUpdatedAt = settings.MaxEnergy, nowMs
+		return
+	}
+	// energy accrues like mining, capped by the missing energy
+	gained, usedMs := utils.CalPointForMining(settings.EnergyRegenPerSec*3600, settings.MaxEnergy-u.Energy, nowMs-u.EnergyUpdatedAt)
+	u.Energy, u.EnergyUpdatedAt = u.Energy+gained, u.EnergyUpdatedAt+usedMs
+	if u.Energy >= settings.MaxEnergy {
+		u.EnergyUpdatedAt = nowMs
+	}
+}
+
+// Status tap state at nowMs
+func (u *Tap) Status(nowMs int64, config *configs.TapConfig) *TapStatus {
+	settings := tapSettings(config)
+	tap := *u
+	tap.regen(nowMs, &settings)
+	return &TapStatus{Tap: &tap, MaxEnergy: settings.MaxEnergy, EnergyRegenPerSec: settings.EnergyRegenPerSec, PointsPerTap: settings.PointsPerTap}
+}
+
+// checkBatch return why a batch of taps between client ts startedAt and endedAt is impossible, empty if it is possible
+func (u *Tap) checkBatch(taps int64, startedAt int64, endedAt int64, nowMs int64, settings *configs.TapConfig) string {
+	switch {
+	case endedAt > nowMs+tapMaxClockSkewMs:
+		return "batch ends in the future"
+	case startedAt < nowMs-tapMaxBatchAgeMs:
+		return "batch is too old"
+	case startedAt < u.LastEndedAt:
+		return "batch overlaps a previous batch"
+	}
+	// a batch within a second may hold a second of taps
+	if maxTaps := settings.MaxTapsPerSec * max(endedAt-startedAt, 1000) / 1000; taps > maxTaps {
+		return fmt.Sprintf("%d taps in %dms is faster than %d taps per second", taps, endedAt-startedAt, settings.MaxTapsPerSec)
+	}
+	return ""
+}
+
+// reject record a rejected batch, rejects are counted in a window starting at the first reject, a window older than
+// flag window starts over, so rare rejects spread over a long time never flag the user
+func (u *Tap) reject(reason string, nowMs int64, settings *configs.TapConfig) {
+	if nowMs-u.WindowStartedAt >= settings.FlagWindowSec*1000 {
+		u.WindowRejects, u.WindowStartedAt = 0, nowMs
+	}
+	u.RejectedBatches++
+	u.WindowRejects++
+	u.LastRejectReason = reason
+	u.Flagged = u.WindowRejects >= settings.FlagAfterRejects
+}
+
+// accept record an accepted batch ending at client ts endedAt, taps are credited as far as energy regenerated until nowMs
+// affords. Return credited taps
+func (u *Tap) accept(taps int64, endedAt int64, nowMs int64, settings *configs.TapConfig) int64 {
+	u.regen(nowMs, settings)
+	credited := min(taps, u.Energy)
+	u.Energy -= credited
+	u.LastEndedAt = endedAt
+	u.TotalTaps += credited
+	u.TotalPointValue += credited * settings.PointsPerTap
+	u.AcceptedBatches++
+	u.ClampedTaps += taps - credited
+	return credited
+}
+
+func newTap(uid int64, config *configs.TapConfig) *Tap {
+	return &Tap{Uid: uid, Energy: tapSettings(config).MaxEnergy, EnergyUpdatedAt: time.Now().UnixMilli()}
+}
+
+// TapFindOrCreate find a user's tap state, create it with full energy if not exists
+func (s *Service) TapFindOrCreate(uid int64, config *configs.TapConfig) (*Tap, error) {
+	var tap Tap
+	if e := s.DBInstance.Where(Tap{Uid: uid}).Attrs(newTap(uid, config)).FirstOrCreate(&tap).Error; e != nil {
+		return nil, e
+	} else {
+		return &tap, nil
+	}
+}
+
+// findOrCreateTapForUpdate find a user's tap state and lock it until transaction ends, if not exists, create it
+func findOrCreateTapForUpdate(db *gorm.DB, uid int64, config *configs.TapConfig, tap *Tap) error {
+	if e0 := db.Where(Tap{Uid: uid}).Attrs(newTap(uid, config)).FirstOrCreate(tap).Error; e0 != nil {
+		return e0
+	}
+	return db.Clauses(clause.Locking{Strength: "UPDATE"}).Where("uid = ?", uid).First(tap).Error
+}
+
+// TapSubmit validate a batch of taps and credit points for taps the energy pool can afford.
+// An impossible batch is recorded and rejected with ErrTapRejected, the user is flagged after too many rejects in a window
+func (s *Service) TapSubmit(uid int64, taps int64, startedAt int64, endedAt int64, config *configs.TapConfig) (*TapStatus, *Point, error) {
+	settings := tapSettings(config)
+	var tap Tap
+	var point *Point
+	var rejected error
+	submittedAt := time.Now().UnixMilli()
+	e := s.DBInstance.Transaction(func(tx *gorm.DB) error {
+		if e0 := findOrCreateTapForUpdate(tx, uid, config, &tap); e0 != nil {
+			return e0
+		}
+		if tap.Flagged {
+			rejected = ErrTapFlagged
+			return nil
+		}
+
+		if reason := tap.checkBatch(taps, startedAt, endedAt, submittedAt, &settings); reason != "" {
+			tap.reject(reason, submittedAt, &settings)
+			rejected = fmt.Errorf("%w: %s", ErrTapRejected, reason)
+			return tx.Save(&tap).Error
+		}
+
+		credited := tap.accept(taps, endedAt, submittedAt, &settings)
+		if e2 := tx.Save(&tap).Error; e2 != nil {
+			return e2
+		}
+		if _point, e3 := s.PointClaimForTap(tx, uid, credited*settings.PointsPerTap); e3 != nil {
+			return e3
+		} else {
+			point = _point
+		}
+		return nil
+	})
+	if e != nil {
+		return nil, nil, e
+	}
+	return tap.Status(submittedAt, config), point, rejected
+}
+
+// TapUnflag clear a user's flag after review, the reject window starts over. gorm.ErrRecordNotFound if user never tapped
+func (s *Service) TapUnflag(uid int64) (*Tap, error) {
+	var tap Tap
+	e := s.DBInstance.Transaction(func(tx *gorm.DB) error {
+		if e0 := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("uid = ?", uid).First(&tap).Error; e0 != nil {
+			return e0
+		}
+		tap.Flagged, tap.WindowRejects, tap.WindowStartedAt = false, 0, 0
+		return tx.Save(&tap).Error
+	})
+	if e != nil {
+		return nil, e
+	}
+	return &tap, nil
+}
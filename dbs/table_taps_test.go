@@ -0,0 +1,144 @@
+package dbs
+
+import (
+	"game-mining-server/configs"
+	"strings"
+	"testing"
+)
+
+const testNowMs = int64(1700000000000)
+
+func testTapSettings() configs.TapConfig {
+	return tapSettings(&configs.TapConfig{MaxEnergy: 1000, EnergyRegenPerSec: 3, PointsPerTap: 2, MaxTapsPerSec: 15, FlagAfterRejects: 3, FlagWindowSec: 3600})
+}
+
+func TestTapCheckBatch(t *testing.T) {
+	settings := testTapSettings()
+	tap := &Tap{LastEndedAt: testNowMs - 60*1000}
+	cases := []struct {
+		name      string
+		taps      int64
+		startedAt int64
+		endedAt   int64
+		reason    string // substring of reject reason, empty if accepted
+	}{
+		{"human rate", 60, testNowMs - 5000, testNowMs - 1000, ""},
+		{"exactly max rate", 60, testNowMs - 4000, testNowMs, ""},
+		{"short batch holds a second of taps", 15, testNowMs - 100, testNowMs, ""},
+		{"faster than max rate", 61, testNowMs - 4000, testNowMs, "faster than 15 taps per second"},
+		{"short batch over a second of taps", 16, testNowMs - 100, testNowMs, "faster than"},
+		{"starts where previous ended", 1, testNowMs - 60*1000, testNowMs, ""},
+		{"overlaps previous batch", 1, testNowMs - 60*1000 - 1, testNowMs, "overlaps"},
+		{"within clock skew", 1, testNowMs, testNowMs + tapMaxClockSkewMs, ""},
+		{"ends in the future", 1, testNowMs, testNowMs + tapMaxClockSkewMs + 1, "future"},
+		{"10 minutes old", 1, testNowMs - tapMaxBatchAgeMs, testNowMs, "overlaps"},
+		{"older than 10 minutes", 1, testNowMs - tapMaxBatchAgeMs - 1, testNowMs, "too old"},
+	}
+	for _, c := range cases {
+		reason := tap.checkBatch(c.taps, c.startedAt, c.endedAt, testNowMs, &settings)
+		if c.reason == "" && reason != "" {
+			t.Errorf("%s: want accepted, got %q", c.name, reason)
+		} else if c.reason != "" && !strings.Contains(reason, c.reason) {
+			t.Errorf("%s: want reason containing %q, got %q", c.name, c.reason, reason)
+		}
+	}
+
+	// the age check applies before overlap for a user who never tapped
+	fresh := &Tap{}
+	if reason := fresh.checkBatch(1, testNowMs-tapMaxBatchAgeMs, testNowMs, testNowMs, &settings); reason != "" {
+		t.Errorf("batch started 10 minutes ago: want accepted, got %q", reason)
+	}
+}
+
+func TestTapRegen(t *testing.T) {
+	settings := testTapSettings()
+	cases := []struct {
+		name            string
+		energy          int64
+		elapsedMs       int64
+		wantEnergy      int64
+		wantUpdatedAtMs int64 // relative to testNowMs
+	}{
+		{"regenerates per second", 0, 10000, 30, 0},
+		{"keeps time not used by a whole energy", 0, 500, 1, -500 + 333},
+		{"no time elapsed", 10, 0, 10, 0},
+		{"capped at max energy", 990, 10000, 1000, 0},
+		{"full pool restarts from now", 1000, 10000, 1000, 0},
+	}
+	for _, c := range cases {
+		tap := &Tap{Energy: c.energy, EnergyUpdatedAt: testNowMs - c.elapsedMs}
+		tap.regen(testNowMs, &settings)
+		if tap.Energy != c.wantEnergy || tap.EnergyUpdatedAt != testNowMs+c.wantUpdatedAtMs {
+			t.Errorf("%s: want energy %d updated at %d, got %d at %d", c.name, c.wantEnergy, testNowMs+c.wantUpdatedAtMs, tap.Energy, tap.EnergyUpdatedAt)
+		}
+	}
+}
+
+func TestTapAcceptClampsToEnergy(t *testing.T) {
+	settings := testTapSettings()
+	cases := []struct {
+		name         string
+		energy       int64
+		elapsedMs    int64
+		taps         int64
+		wantCredited int64
+		wantEnergy   int64
+	}{
+		{"enough energy", 100, 0, 40, 40, 60},
+		{"clamped to energy left", 10, 0, 40, 10, 0},
+		{"regenerated energy counts", 10, 5000, 40, 25, 0},
+		{"no energy", 0, 0, 40, 0, 0},
+	}
+	for _, c := range cases {
+		tap := &Tap{Energy: c.energy, EnergyUpdatedAt: testNowMs - c.elapsedMs, TotalTaps: 5, ClampedTaps: 1, AcceptedBatches: 1}
+		credited := tap.accept(c.taps, testNowMs-100, testNowMs, &settings)
+		if credited != c.wantCredited || tap.Energy != c.wantEnergy {
+			t.Errorf("%s: want %d credited with %d energy left, got %d with %d", c.name, c.wantCredited, c.wantEnergy, credited, tap.Energy)
+		}
+		if tap.TotalTaps != 5+c.wantCredited || tap.TotalPointValue != c.wantCredited*settings.PointsPerTap ||
+			tap.ClampedTaps != 1+c.taps-c.wantCredited || tap.AcceptedBatches != 2 || tap.LastEndedAt != testNowMs-100 {
+			t.Errorf("%s: bad counters %+v", c.name, tap)
+		}
+	}
+}
+
+func TestTapRejectWindow(t *testing.T) {
+	settings := testTapSettings() // 3 rejects within an hour flag the user
+	windowMs := settings.FlagWindowSec * 1000
+	cases := []struct {
+		name        string
+		rejectsAtMs []int64 // relative to testNowMs
+		wantRejects int64   // rejects counted in current window
+		wantFlagged bool
+	}{
+		{"below threshold", []int64{0, 1000}, 2, false},
+		{"threshold within window", []int64{0, 1000, windowMs - 1}, 3, true},
+		{"window expires before threshold", []int64{0, 1000, windowMs}, 1, false},
+		{"threshold in a later window", []int64{0, windowMs, windowMs + 1, windowMs + 2}, 3, true},
+		{"rare rejects never flag", []int64{0, 2 * windowMs, 4 * windowMs, 6 * windowMs, 8 * windowMs}, 1, false},
+	}
+	for _, c := range cases {
+		tap := &Tap{}
+		for _, at := range c.rejectsAtMs {
+			tap.reject("batch is too old", testNowMs+at, &settings)
+		}
+		if tap.WindowRejects != c.wantRejects || tap.Flagged != c.wantFlagged || tap.RejectedBatches != int64(len(c.rejectsAtMs)) {
+			t.Errorf("%s: want %d window rejects flagged %v, got %d flagged %v of %d", c.name, c.wantRejects, c.wantFlagged,
+				tap.WindowRejects, tap.Flagged, tap.RejectedBatches)
+		}
+		if tap.LastRejectReason != "batch is too old" {
+			t.Errorf("%s: want last reject reason, got %q", c.name, tap.LastRejectReason)
+		}
+	}
+}
+
+func TestTapSettingsDefaults(t *testing.T) {
+	settings := tapSettings(nil)
+	if settings.MaxEnergy != configs.TapMaxEnergy || settings.MaxTapsPerSec != configs.TapMaxTapsPerSec ||
+		settings.FlagAfterRejects != configs.TapFlagAfterRejects || settings.FlagWindowSec != configs.TapFlagWindowSec {
+		t.Errorf("want defaults, got %+v", settings)
+	}
+	if partial := tapSettings(&configs.TapConfig{MaxTapsPerSec: 20}); partial.MaxTapsPerSec != 20 || partial.MaxEnergy != configs.TapMaxEnergy {
+		t.Errorf("want zero values to fall back to defaults, got %+v", partial)
+	}
+}
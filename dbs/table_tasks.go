@@ -68,7 +68,7 @@ func createUserInitTasks(uid int64) []*Task {
 	return []*Task{socialSubscribeTgChannelTask, socialFollowCfOnXTask, socialRtAnnTask}
 }
 
-func (s *Service) TaskClaim(id string, uid int64, fromStatus int, toStatus int) (*Point, error) {
+func (s *Service) TaskClaim(id string, uid int64, fromStatus int, toStatus int, config *configs.Config) (*Point, error) {
 	var point *Point
 	e := s.DBInstance.Transaction(func(tx *gorm.DB) error {
 		var task Task
@@ -84,7 +84,7 @@ func (s *Service) TaskClaim(id string, uid int64, fromStatus int, toStatus int)
 		} else {
 			point = _point
 		}
-		return nil
+		return s.grantTaskBoosters(tx, uid, task.TaskType, config)
 	})
 	return point, e
 }
@@ -1,6 +1,7 @@
 package dbs
 
 import (
+	"game-mining-server/configs"
 	"game-mining-server/entities"
 	"time"
 )
@@ -83,8 +84,33 @@ func (s *Service) UserCountInvitedUsers(uid int64) (int64, error) {
 	}
 }
 
-// RefreshUserRewardPoints refresh user's reward points everyday
-func (s *Service) RefreshUserRewardPoints(userID int64) error {
+// Perks perks of user, premium users get perks in config with defaults for zero values, other users get none
+func (u *User) Perks(config *configs.PremiumConfig) *configs.PremiumConfig {
+	if !u.IsPremium {
+		return &configs.PremiumConfig{CheckinMultiplier: 1, DailyRewardPoints: configs.DailyRewardPoints}
+	}
+	perks := &configs.PremiumConfig{
+		CheckinMultiplier:   configs.PremiumCheckinMultiplier,
+		DailyRewardPoints:   configs.PremiumDailyRewardPoints,
+		ExtraMiningCapacity: configs.PremiumExtraMiningCapacity,
+	}
+	if config == nil {
+		return perks
+	}
+	if config.CheckinMultiplier > 0 {
+		perks.CheckinMultiplier = config.CheckinMultiplier
+	}
+	if config.DailyRewardPoints > 0 {
+		perks.DailyRewardPoints = config.DailyRewardPoints
+	}
+	if config.ExtraMiningCapacity > 0 {
+		perks.ExtraMiningCapacity = config.ExtraMiningCapacity
+	}
+	return perks
+}
+
+// RefreshUserRewardPoints refresh user's reward points everyday, premium users get a larger allowance
+func (s *Service) RefreshUserRewardPoints(userID int64, config *configs.PremiumConfig) error {
 	var user User
 	if err := s.DBInstance.First(&user, userID).Error; err != nil {
 		return err
@@ -98,7 +124,7 @@ func (s *Service) RefreshUserRewardPoints(userID int64) error {
 	if currentDate.After(lastRefreshDate) {
 		// update user reward points
 		err := s.DBInstance.Model(&user).Updates(map[string]interface{}{
-			"RewardPoints":      200,
+			"RewardPoints":      user.Perks(config).DailyRewardPoints,
 			"LastPointsRefresh": time.Now().UTC(),
 		}).Error
 		if err != nil {
@@ -111,3 +137,13 @@ func (s *Service) RefreshUserRewardPoints(userID int64) error {
 
 	return nil
 }
+
+// UserFindIdsAfter return user ids greater than afterUid in ascending order, used to iterate all users in batches
+func (s *Service) UserFindIdsAfter(afterUid int64, limit int) ([]int64, error) {
+	var ids []int64
+	if e := s.DBInstance.Model(&User{}).Where("id > ?", afterUid).Order("id asc").Limit(limit).Pluck("id", &ids).Error; e != nil {
+		return nil, e
+	} else {
+		return ids, nil
+	}
+}
@@ -11,6 +11,7 @@ const (
 	ErrGenUserSessionFailed = 1005 // generate user session and set to cache failed
 	ErrUserNotFound         = 1006 // not found user in database or cache
 	ErrUserAuthExpired      = 1007 // user auth expired
+	ErrPermissionDenied     = 1008 // user has no permission
 
 	// ErrInternalDBInsertFailed start Internal error code
 	ErrInternalDBInsertFailed      = 2000
@@ -18,9 +19,32 @@ const (
 	ErrInternalDBUpdateFailed      = 2002
 	ErrInternalDBDeleteFailed      = 2003
 	ErrInternalGenerateTokenFailed = 2004
+	ErrInternalCacheQueryFailed    = 2005
 
 	ErrProxyCreateRequestFailed = 3001
 	ErrProxyRequestFailed       = 3002
 	ErrProxyParseResBodyFailed  = 3003
 	ErrProxyReadResBodyFailed   = 3004
+	ErrProxyUrlNotAllowed       = 3005
+	ErrProxyBodyTooLarge        = 3006
+	ErrProxyTooManyConnections  = 3007
+	ErrProxyUpgradeFailed       = 3008
+
+	ErrBotWebhookDisabled      = 4001
+	ErrBotWebhookInvalidSecret = 4002
+	ErrBotWebhookHandleFailed  = 4003
+
+	ErrRpcChainNotFound = 5001
+
+	ErrPriceUnavailable      = 6001
+	ErrPriceFiatNotSupported = 6002
+	ErrPriceAlertLimit       = 6003
+	ErrPriceCoinNotSupported = 6004
+
+	ErrMiningNothingToClaim = 7001
+	ErrNotEnoughPoints      = 7002
+	ErrUpgradeMaxLevel      = 7003
+	ErrTapRejected          = 7004
+	ErrTapFlagged           = 7005
+	ErrBoosterNotForSale    = 7006
 )
@@ -9,6 +9,24 @@ type CoinPriceParam struct {
 	FiatSymbol  string `form:"fiatSymbol" binding:"required,alpha,min=1,max=10"`
 }
 
+type PortfolioBalanceParam struct {
+	CoinSymbol string  `json:"coinSymbol" binding:"required,max=32"` // coin symbol: BTC
+	Amount     float64 `json:"amount" binding:"gte=0"`               // balance in coin units, not in smallest units
+}
+
+type PortfolioParam struct {
+	FiatSymbol string                   `json:"fiatSymbol" binding:"omitempty,alpha,max=10"` // USD if empty
+	Balances   []*PortfolioBalanceParam `json:"balances" binding:"required,min=1,max=100,dive,required"`
+}
+
+type CoinPriceHistoryParam struct {
+	CoinSymbol string `form:"coinSymbol" binding:"required,alphanum,max=20"`     // BTC
+	Interval   string `form:"interval" binding:"required,oneof=1h 1d"`           // candle interval
+	From       int64  `form:"from" binding:"required,min=1"`                     // unix seconds of the first candle
+	To         int64  `form:"to" binding:"omitempty,min=1"`                      // unix seconds of the last candle, default now
+	FiatSymbol string `form:"fiatSymbol" binding:"omitempty,alpha,min=1,max=10"` // default USD
+}
+
 type UserLoginParam struct {
 	InitDataRaw string `json:"initDataRaw" binding:"required"`
 	Referral    string `json:"referral" binding:"omitempty,alphanum,min=1,max=20"`
@@ -35,7 +53,7 @@ type LeaderBoardParam struct {
 }
 
 type CreateMomentParam struct {
-	UserId   int64  `json:"user_id" binding:"required"`
+	UserId   int64  `json:"user_id" binding:"required"` // must be current user
 	Content  string `json:"content" binding:"required"`
 	ImageURL string `json:"image_url"`
 }
@@ -79,3 +97,57 @@ type RewardMomentParam struct {
 	ToUserId   int64 `json:"to_user_id" binding:"required"`
 	Amount     int   `json:"amount" binding:"required"`
 }
+
+type NotificationPrefParam struct {
+	MomentLike      *bool `json:"momentLike"`
+	MomentComment   *bool `json:"momentComment"`
+	MomentReward    *bool `json:"momentReward"`
+	CheckinReminder *bool `json:"checkinReminder"`
+}
+
+type CreateBroadcastParam struct {
+	Text       string `json:"text" binding:"required,max=4096"`                           // message text, or photo caption if photo is set
+	Photo      string `json:"photo" binding:"omitempty,max=1024"`                         // photo url or telegram file id
+	ButtonText string `json:"buttonText" binding:"omitempty,max=64"`                      // inline button text
+	ButtonUrl  string `json:"buttonUrl" binding:"required_with=ButtonText,omitempty,url"` // inline button url
+}
+
+type BroadcastIdParam struct {
+	Id int64 `uri:"id" binding:"required,min=1"`
+}
+
+type CreatePriceAlertParam struct {
+	CoinSymbol  string  `json:"coinSymbol" binding:"required,max=32"`   // coin symbol: BTC
+	FiatSymbol  string  `json:"fiatSymbol" binding:"omitempty,max=8"`   // fiat of target price, USD if empty
+	Direction   int     `json:"direction" binding:"required,oneof=1 2"` // 1: notify when price rises above target, 2: when it falls below
+	TargetPrice float64 `json:"targetPrice" binding:"required,gt=0"`    // target price in fiat
+	Recurring   bool    `json:"recurring"`                              // notify on every crossing instead of only once
+}
+
+type UpdatePriceAlertParam struct {
+	Direction   *int     `json:"direction" binding:"omitempty,oneof=1 2"`
+	TargetPrice *float64 `json:"targetPrice" binding:"omitempty,gt=0"`
+	Recurring   *bool    `json:"recurring"`
+}
+
+type PriceAlertIdParam struct {
+	Id int64 `uri:"id" binding:"required,min=1"`
+}
+
+type MiningUpgradeParam struct {
+	Type string `json:"type" binding:"required,oneof=rate capacity autoClaim"`
+}
+
+type TapParam struct {
+	Taps      int64 `json:"taps" binding:"required,min=1,max=10000"`       // tap count in batch
+	StartedAt int64 `json:"startedAt" binding:"required,min=1"`            // client ts of first tap: 1670400478555
+	EndedAt   int64 `json:"endedAt" binding:"required,gtefield=StartedAt"` // client ts of last tap: 1670400478555
+}
+
+type TapUidParam struct {
+	Uid int64 `uri:"uid" binding:"required,min=1"`
+}
+
+type BuyBoosterParam struct {
+	BoosterId string `json:"boosterId" binding:"required,max=64"`
+}
@@ -1,9 +1,10 @@
 package entities
 
 type BaseResponse struct {
-	Code int         `json:"code"`
-	Msg  string      `json:"msg,omitempty"`
-	Data interface{} `json:"data,omitempty"`
+	Code   int         `json:"code"`
+	Msg    string      `json:"msg,omitempty"`
+	Detail string      `json:"detail,omitempty"`
+	Data   interface{} `json:"data,omitempty"`
 }
 
 func ResSuccess[T any](data T) BaseResponse {
@@ -19,3 +20,12 @@ func ResFailed(code int, msg string) BaseResponse {
 		Msg:  msg,
 	}
 }
+
+// ResFailedDetail failed response with a readable msg and a raw error detail
+func ResFailedDetail(code int, msg string, detail string) BaseResponse {
+	return BaseResponse{
+		Code:   code,
+		Msg:    msg,
+		Detail: detail,
+	}
+}
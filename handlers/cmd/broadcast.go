@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"game-mining-server/app"
+	"game-mining-server/configs"
+	"game-mining-server/dbs"
+	"game-mining-server/i18n"
+	"github.com/mymmrac/telego"
+	tu "github.com/mymmrac/telego/telegoutil"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+var broadcastStatusNames = map[int]string{
+	configs.BroadcastStatusRunning:   "running",
+	configs.BroadcastStatusPaused:    "paused",
+	configs.BroadcastStatusCancelled: "cancelled",
+	configs.BroadcastStatusFinished:  "finished",
+}
+
+// HandleCmdBroadcast Admin only command to create and control broadcasts, non-admin users are ignored
+// /broadcast send <text>, /broadcast status|pause|resume|cancel <id>
+func HandleCmdBroadcast(bot *telego.Bot, update telego.Update) {
+	message := update.Message
+	if message.From == nil || !app.Config().Bot.IsAdmin(message.From.ID) {
+		return
+	}
+	lang := MessageLanguage(message)
+	reply := func(text string) {
+		_, _ = bot.SendMessage(tu.Message(tu.ID(message.Chat.ID), text))
+	}
+
+	_, _, payload := tu.ParseCommandPayload(message.Text)
+	action, arg := payload, ""
+	if i := strings.IndexFunc(payload, unicode.IsSpace); i >= 0 {
+		action, arg = payload[:i], strings.TrimSpace(payload[i:])
+	}
+
+	if action == "send" && arg != "" {
+		broadcast := &dbs.Broadcast{CreatedBy: message.From.ID, Text: arg}
+		// reply to a photo message to broadcast the photo, use the largest size
+		if replyTo := message.ReplyToMessage; replyTo != nil && len(replyTo.Photo) > 0 {
+			broadcast.Photo = replyTo.Photo[len(replyTo.Photo)-1].FileID
+		}
+		if e0 := app.DB().BroadcastCreate(broadcast); e0 != nil {
+			reply(i18n.T(lang, i18n.MsgBroadcastFailed, e0.Error()))
+		} else {
+			reply(i18n.T(lang, i18n.MsgBroadcastCreated, strconv.FormatInt(broadcast.Id, 10), strconv.FormatInt(broadcast.Total, 10)))
+		}
+		return
+	}
+
+	id, e1 := strconv.ParseInt(arg, 10, 64)
+	if e1 != nil {
+		reply(i18n.T(lang, i18n.MsgBroadcastUsage))
+		return
+	}
+	var broadcast *dbs.Broadcast
+	var err error
+	switch action {
+	case "status":
+		broadcast, err = app.DB().BroadcastFindById(id)
+	case "pause":
+		broadcast, err = app.DB().BroadcastUpdateStatus(id, configs.BroadcastStatusPaused)
+	case "resume":
+		broadcast, err = app.DB().BroadcastUpdateStatus(id, configs.BroadcastStatusRunning)
+	case "cancel":
+		broadcast, err = app.DB().BroadcastUpdateStatus(id, configs.BroadcastStatusCancelled)
+	default:
+		reply(i18n.T(lang, i18n.MsgBroadcastUsage))
+		return
+	}
+	if err != nil {
+		reply(i18n.T(lang, i18n.MsgBroadcastFailed, err.Error()))
+		return
+	}
+	reply(i18n.T(lang, i18n.MsgBroadcastStatus, strconv.FormatInt(broadcast.Id, 10), broadcastStatusNames[broadcast.Status],
+		strconv.FormatInt(broadcast.Delivered, 10), strconv.FormatInt(broadcast.Blocked, 10),
+		strconv.FormatInt(broadcast.Failed, 10), strconv.FormatInt(broadcast.Total, 10)))
+}
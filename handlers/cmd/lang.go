@@ -0,0 +1,14 @@
+package cmd
+
+import (
+	"game-mining-server/i18n"
+	"github.com/mymmrac/telego"
+)
+
+// MessageLanguage return the supported language of message sender, fallback to default language
+func MessageLanguage(message *telego.Message) string {
+	if message == nil || message.From == nil {
+		return i18n.LangDefault
+	}
+	return i18n.Normalize(message.From.LanguageCode)
+}
@@ -1,14 +1,35 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
+	"game-mining-server/app"
+	"game-mining-server/caches"
 	"game-mining-server/configs"
 	"game-mining-server/handlers/cmd"
+	"game-mining-server/i18n"
+	"game-mining-server/utils"
+	"github.com/go-redsync/redsync/v4"
 	"github.com/mymmrac/telego"
 	th "github.com/mymmrac/telego/telegohandler"
 	"log"
+	"time"
 )
 
+const (
+	botWebhookFingerprintExpirySec = 24 * 3600       // webhook is set again at least once a day
+	botSetWebhookTries             = 3               // setWebhook attempts before startup fails
+	botSetWebhookRetryDelay        = 2 * time.Second // delay between setWebhook attempts
+)
+
+// webhookHandler receives raw webhook updates, registered by telego and served by http server in webhook mode
+var webhookHandler telego.WebhookHandler
+
+// WebhookHandler return the bot webhook handler, nil if bot is not running in webhook mode
+func WebhookHandler() telego.WebhookHandler {
+	return webhookHandler
+}
+
 func RegisterBotAndRun(bot *telego.Bot, config *configs.BotConfig) error {
 	if bot == nil {
 		log.Println("Not register bot handlers")
@@ -19,7 +40,7 @@ func RegisterBotAndRun(bot *telego.Bot, config *configs.BotConfig) error {
 	} else {
 		log.Printf("Register bot(%s) handlers\n", botUser.Username)
 	}
-	updates, e0 := bot.UpdatesViaLongPolling(nil)
+	updates, e0 := createUpdates(bot, config)
 	if e0 != nil {
 		return e0
 	}
@@ -36,11 +57,77 @@ func RegisterBotAndRun(bot *telego.Bot, config *configs.BotConfig) error {
 	return nil
 }
 
+// createUpdates create bot updates chan by config mode, in webhook mode updates are pushed by http server
+func createUpdates(bot *telego.Bot, config *configs.BotConfig) (<-chan telego.Update, error) {
+	if config.Mode != configs.BotModeWebhook {
+		// telegram rejects long polling while a webhook is set, e.g. after switching back from webhook mode
+		if e0 := bot.DeleteWebhook(&telego.DeleteWebhookParams{}); e0 != nil {
+			return nil, fmt.Errorf("Delete bot webhook failed: %s", e0)
+		}
+		app.Cache().Delete(caches.GenBotWebhookCacheKey())
+		return bot.UpdatesViaLongPolling(nil)
+	}
+	if config.WebhookHost == "" || config.WebhookPath == "" || config.WebhookSecret == "" {
+		return nil, errors.New("webhook mode requires webhookHost, webhookPath and webhookSecret")
+	}
+
+	updates, e0 := bot.UpdatesViaWebhook(config.WebhookPath, telego.WithWebhookServer(telego.NoOpWebhookServer{
+		RegisterHandlerFunc: func(_ string, handler telego.WebhookHandler) error {
+			webhookHandler = handler
+			return nil
+		},
+	}))
+	if e0 != nil {
+		return nil, e0
+	}
+	if e1 := setWebhookOnce(bot, config); e1 != nil {
+		return nil, e1
+	}
+	return updates, nil
+}
+
+// setWebhookOnce set webhook to telegram, only the replica which holds the lock will set it and others just skip
+func setWebhookOnce(bot *telego.Bot, config *configs.BotConfig) error {
+	mutex := app.Cache().RedSyncLock.NewMutex(caches.GenLockCacheKey("botWebhook"), redsync.WithExpiry(30*time.Second), redsync.WithTries(1))
+	if e0 := mutex.Lock(); e0 != nil {
+		log.Println("Set bot webhook skipped, other replica is setting it")
+		return nil
+	}
+	defer func() {
+		_, _ = mutex.Unlock()
+	}()
+
+	// webhook fingerprint contains bot token, url and secret, so changing any of them will set webhook again,
+	// and it expires in case webhook is changed outside
+	webhookUrl := config.WebhookHost + config.WebhookPath
+	fingerprint := utils.Sha256(bot.Token() + ":" + webhookUrl + ":" + config.WebhookSecret)
+	if cached, e1 := app.Cache().GetString(caches.GenBotWebhookCacheKey()); e1 == nil && cached == fingerprint {
+		log.Printf("Bot webhook already set: %s\n", webhookUrl)
+		return nil
+	}
+	var e2 error
+	for i := 0; i < botSetWebhookTries; i++ {
+		if i > 0 {
+			log.Printf("Set bot webhook failed, retrying: %s\n", e2)
+			time.Sleep(botSetWebhookRetryDelay)
+		}
+		if e2 = bot.SetWebhook(&telego.SetWebhookParams{URL: webhookUrl, SecretToken: config.WebhookSecret}); e2 == nil {
+			break
+		}
+	}
+	if e2 != nil {
+		return fmt.Errorf("Set bot webhook failed: %s", e2)
+	}
+	_ = app.Cache().SetString(caches.GenBotWebhookCacheKey(), fingerprint, botWebhookFingerprintExpirySec)
+	log.Printf("Set bot webhook success: %s\n", webhookUrl)
+	return nil
+}
+
 func createBotMenu(bot *telego.Bot, config *configs.BotConfig) error {
 	e0 := bot.SetChatMenuButton(&telego.SetChatMenuButtonParams{
 		MenuButton: &telego.MenuButtonWebApp{
 			Type: "web_app",
-			Text: "💰Wallet",
+			Text: i18n.T(i18n.LangDefault, i18n.MsgMenuWallet),
 			WebApp: telego.WebAppInfo{
 				URL: config.WebUrl,
 			},
@@ -52,16 +139,20 @@ func createBotMenu(bot *telego.Bot, config *configs.BotConfig) error {
 		log.Println("Create bot menu button success")
 	}
 
-	e1 := bot.SetMyCommands(&telego.SetMyCommandsParams{
-		Commands: []telego.BotCommand{
-			{
-				Command:     "start",
-				Description: "start",
+	// default commands for all users, and localized commands for each supported language
+	for _, lang := range append([]string{""}, i18n.Languages()...) {
+		e1 := bot.SetMyCommands(&telego.SetMyCommandsParams{
+			Commands: []telego.BotCommand{
+				{
+					Command:     "start",
+					Description: i18n.T(lang, i18n.MsgCmdStartDesc),
+				},
 			},
-		},
-	})
-	if e1 != nil {
-		return fmt.Errorf("Create bot set commands failed: " + e1.Error())
+			LanguageCode: lang,
+		})
+		if e1 != nil {
+			return fmt.Errorf("Create bot set commands failed: " + e1.Error())
+		}
 	}
 	log.Println("Create bot set commands success")
 	return nil
@@ -69,4 +160,5 @@ func createBotMenu(bot *telego.Bot, config *configs.BotConfig) error {
 
 func registerHandlers(bh *th.BotHandler) {
 	bh.Handle(cmd.HandleCmdStart, th.CommandEqual("start"))
+	bh.Handle(cmd.HandleCmdBroadcast, th.CommandEqual("broadcast"))
 }
@@ -0,0 +1,93 @@
+package i18n
+
+import (
+	"fmt"
+	"game-mining-server/entities"
+	"strings"
+)
+
+// Supported language codes, LangDefault is used when user language is missing or not supported
+const (
+	LangEN      = "en"
+	LangRU      = "ru"
+	LangZH      = "zh"
+	LangES      = "es"
+	LangDefault = LangEN
+)
+
+// Message keys for bot messages
+const (
+	MsgCmdStartDesc = "cmd.start"
+	MsgMenuWallet   = "menu.wallet"
+	MsgOpenWallet   = "start.openWallet"
+	MsgStartCaption = "start.caption"
+
+	MsgNotifyMomentLike      = "notify.momentLike"
+	MsgNotifyMomentComment   = "notify.momentComment"
+	MsgNotifyMomentReward    = "notify.momentReward"
+	MsgNotifyCheckinReminder = "notify.checkinReminder"
+	MsgNotifyPriceAboveAlert = "notify.priceAboveAlert"
+	MsgNotifyPriceBelowAlert = "notify.priceBelowAlert"
+	MsgNotifyAchievement     = "notify.achievement"
+	MsgNotifySomeone         = "notify.someone"
+
+	MsgBroadcastUsage   = "broadcast.usage"
+	MsgBroadcastCreated = "broadcast.created"
+	MsgBroadcastStatus  = "broadcast.status"
+	MsgBroadcastFailed  = "broadcast.failed"
+)
+
+// Catalog contains all translated messages and error messages of a language
+type Catalog struct {
+	Messages map[string]string // message key -> text
+	Errors   map[int]string    // entities error code -> text
+}
+
+var catalogs = map[string]*Catalog{
+	LangEN: &en,
+	LangRU: &ru,
+	LangZH: &zh,
+	LangES: &es,
+}
+
+// Languages return all supported language codes
+func Languages() []string {
+	return []string{LangEN, LangRU, LangZH, LangES}
+}
+
+// Normalize convert a language tag to a supported language code, e.g: zh-hans -> zh, en-US -> en, fr -> en
+func Normalize(lang string) string {
+	lang = strings.ToLower(strings.TrimSpace(lang))
+	if i := strings.IndexAny(lang, "-_"); i >= 0 {
+		lang = lang[:i]
+	}
+	if _, ok := catalogs[lang]; ok {
+		return lang
+	}
+	return LangDefault
+}
+
+// T translate a message key to specified language, fallback to English, args are applied by fmt.Sprintf
+func T(lang string, key string, args ...interface{}) string {
+	text, ok := catalogs[Normalize(lang)].Messages[key]
+	if !ok {
+		if text, ok = catalogs[LangDefault].Messages[key]; !ok {
+			return key
+		}
+	}
+	if len(args) > 0 {
+		return fmt.Sprintf(text, args...)
+	}
+	return text
+}
+
+// Err translate an entities error code to specified language, fallback to English and then unknown error
+func Err(lang string, code int) string {
+	if text, ok := catalogs[Normalize(lang)].Errors[code]; ok {
+		return text
+	}
+	if text, ok := catalogs[LangDefault].Errors[code]; ok {
+		return text
+	}
+	return catalogs[LangDefault].Errors[entities.ErrUnknown]
+}
@@ -0,0 +1,64 @@
+package i18n
+
+import "game-mining-server/entities"
+
+var en = Catalog{
+	Messages: map[string]string{
+		MsgCmdStartDesc:          "start",
+		MsgMenuWallet:            "💰Wallet",
+		MsgOpenWallet:            "Open Wallet",
+		MsgStartCaption:          "Welcome to CodexField Wallet! 🚀\n\nYour gateway to the world of EVM is now at your fingertips. Easily manage your crypto assets, interact with DeFi protocols, and explore the exciting world of Web3, all within Telegram.\n\nStay tuned for more features and updates!",
+		MsgNotifyMomentLike:      "❤️ %s liked your moment",
+		MsgNotifyMomentComment:   "💬 %s commented on your moment: %s",
+		MsgNotifyMomentReward:    "🎁 %s tipped your moment %s points",
+		MsgNotifyCheckinReminder: "⏰ Your daily checkin is waiting! Claim %s points now to keep your %s-day streak.",
+		MsgNotifyPriceAboveAlert: "🔔 %s rose above %s, now %s",
+		MsgNotifyPriceBelowAlert: "🔔 %s fell below %s, now %s",
+		MsgNotifyAchievement:     "🏅 Achievement unlocked: %s! You earned %s points",
+		MsgNotifySomeone:         "Someone",
+		MsgBroadcastUsage:        "Usage:\n/broadcast send <text> - send text to all users, reply to a photo to send it with the text as caption\n/broadcast status <id>\n/broadcast pause <id>\n/broadcast resume <id>\n/broadcast cancel <id>",
+		MsgBroadcastCreated:      "Broadcast #%s created, delivering to %s users",
+		MsgBroadcastStatus:       "Broadcast #%s: %s\nDelivered: %s, blocked: %s, failed: %s, total: %s",
+		MsgBroadcastFailed:       "Broadcast operation failed: %s",
+	},
+	Errors: map[int]string{
+		entities.ErrTooManyRequests:             "Too many requests, please try again later",
+		entities.ErrUnknown:                     "Something went wrong, please try again later",
+		entities.ErrInvalidParams:               "Invalid request parameters",
+		entities.ErrInvalidAuthHeader:           "Authorization failed, please log in again",
+		entities.ErrInvalidInitData:             "Invalid Telegram init data",
+		entities.ErrParseInitDataFailed:         "Failed to parse Telegram init data",
+		entities.ErrGenUserSessionFailed:        "Failed to create session, please try again",
+		entities.ErrUserNotFound:                "User not found, please log in again",
+		entities.ErrUserAuthExpired:             "Session expired, please log in again",
+		entities.ErrPermissionDenied:            "Permission denied",
+		entities.ErrInternalDBInsertFailed:      "Failed to save data, please try again",
+		entities.ErrInternalDBQueryFailed:       "Failed to load data, please try again",
+		entities.ErrInternalDBUpdateFailed:      "Failed to update data, please try again",
+		entities.ErrInternalDBDeleteFailed:      "Failed to delete data, please try again",
+		entities.ErrInternalGenerateTokenFailed: "Failed to generate token, please try again",
+		entities.ErrInternalCacheQueryFailed:    "Failed to read cache, please try again",
+		entities.ErrProxyCreateRequestFailed:    "Failed to create proxy request",
+		entities.ErrProxyRequestFailed:          "Proxy request failed",
+		entities.ErrProxyParseResBodyFailed:     "Failed to parse proxy response",
+		entities.ErrProxyReadResBodyFailed:      "Failed to read proxy response",
+		entities.ErrProxyUrlNotAllowed:          "Proxy target is not allowed",
+		entities.ErrProxyBodyTooLarge:           "Proxy content is too large",
+		entities.ErrProxyTooManyConnections:     "Too many proxy connections, please close some and try again",
+		entities.ErrProxyUpgradeFailed:          "Failed to open proxy connection",
+		entities.ErrBotWebhookDisabled:          "Bot webhook is disabled",
+		entities.ErrBotWebhookInvalidSecret:     "Invalid bot webhook secret",
+		entities.ErrBotWebhookHandleFailed:      "Failed to handle bot update",
+		entities.ErrRpcChainNotFound:            "Chain is not supported",
+		entities.ErrPriceUnavailable:            "Price is unavailable, please try again later",
+		entities.ErrPriceFiatNotSupported:       "Fiat currency is not supported",
+		entities.ErrPriceAlertLimit:             "You have reached the maximum number of price alerts",
+		entities.ErrPriceCoinNotSupported:       "Coin is not supported",
+		entities.ErrMiningNothingToClaim:        "Nothing mined to claim yet",
+		entities.ErrNotEnoughPoints:             "Not enough points",
+		entities.ErrUpgradeMaxLevel:             "Upgrade is already at max level",
+		entities.ErrTapRejected:                 "Taps were rejected",
+		entities.ErrTapFlagged:                  "Tapping is suspended for your account",
+		entities.ErrBoosterNotForSale:           "Booster is not for sale",
+	},
+}
@@ -0,0 +1,64 @@
+package i18n
+
+import "game-mining-server/entities"
+
+var es = Catalog{
+	Messages: map[string]string{
+		MsgCmdStartDesc:          "iniciar",
+		MsgMenuWallet:            "💰Billetera",
+		MsgOpenWallet:            "Abrir billetera",
+		MsgStartCaption:          "¡Bienvenido a CodexField Wallet! 🚀\n\nTu puerta al mundo EVM ahora está al alcance de tu mano. Gestiona fácilmente tus criptoactivos, interactúa con protocolos DeFi y explora el emocionante mundo de Web3, todo dentro de Telegram.\n\n¡Mantente atento a nuevas funciones y actualizaciones!",
+		MsgNotifyMomentLike:      "❤️ %s indicó que le gusta tu momento",
+		MsgNotifyMomentComment:   "💬 %s comentó tu momento: %s",
+		MsgNotifyMomentReward:    "🎁 %s dio una propina de %s puntos a tu momento",
+		MsgNotifyCheckinReminder: "⏰ ¡Tu registro diario te espera! Reclama %s puntos ahora para mantener tu racha de %s días.",
+		MsgNotifyPriceAboveAlert: "🔔 %s subió por encima de %s, ahora %s",
+		MsgNotifyPriceBelowAlert: "🔔 %s bajó por debajo de %s, ahora %s",
+		MsgNotifyAchievement:     "🏅 ¡Logro desbloqueado: %s! Ganaste %s puntos",
+		MsgNotifySomeone:         "Alguien",
+		MsgBroadcastUsage:        "Uso:\n/broadcast send <texto> - envía el texto a todos los usuarios, responde a una foto para enviarla con el texto como pie de foto\n/broadcast status <id>\n/broadcast pause <id>\n/broadcast resume <id>\n/broadcast cancel <id>",
+		MsgBroadcastCreated:      "Difusión #%s creada, se entregará a %s usuarios",
+		MsgBroadcastStatus:       "Difusión #%s: %s\nEntregados: %s, bloqueados: %s, fallidos: %s, total: %s",
+		MsgBroadcastFailed:       "La operación de difusión falló: %s",
+	},
+	Errors: map[int]string{
+		entities.ErrTooManyRequests:             "Demasiadas solicitudes, inténtalo más tarde",
+		entities.ErrUnknown:                     "Algo salió mal, inténtalo más tarde",
+		entities.ErrInvalidParams:               "Parámetros de solicitud no válidos",
+		entities.ErrInvalidAuthHeader:           "Error de autorización, vuelve a iniciar sesión",
+		entities.ErrInvalidInitData:             "Datos de inicio de Telegram no válidos",
+		entities.ErrParseInitDataFailed:         "No se pudieron leer los datos de inicio de Telegram",
+		entities.ErrGenUserSessionFailed:        "No se pudo crear la sesión, inténtalo de nuevo",
+		entities.ErrUserNotFound:                "Usuario no encontrado, vuelve a iniciar sesión",
+		entities.ErrUserAuthExpired:             "La sesión ha caducado, vuelve a iniciar sesión",
+		entities.ErrPermissionDenied:            "Permiso denegado",
+		entities.ErrInternalDBInsertFailed:      "No se pudieron guardar los datos, inténtalo de nuevo",
+		entities.ErrInternalDBQueryFailed:       "No se pudieron cargar los datos, inténtalo de nuevo",
+		entities.ErrInternalDBUpdateFailed:      "No se pudieron actualizar los datos, inténtalo de nuevo",
+		entities.ErrInternalDBDeleteFailed:      "No se pudieron eliminar los datos, inténtalo de nuevo",
+		entities.ErrInternalGenerateTokenFailed: "No se pudo generar el token, inténtalo de nuevo",
+		entities.ErrInternalCacheQueryFailed:    "No se pudo leer la caché, inténtalo de nuevo",
+		entities.ErrProxyCreateRequestFailed:    "No se pudo crear la solicitud del proxy",
+		entities.ErrProxyRequestFailed:          "La solicitud del proxy falló",
+		entities.ErrProxyParseResBodyFailed:     "No se pudo analizar la respuesta del proxy",
+		entities.ErrProxyReadResBodyFailed:      "No se pudo leer la respuesta del proxy",
+		entities.ErrProxyUrlNotAllowed:          "El destino del proxy no está permitido",
+		entities.ErrProxyBodyTooLarge:           "El contenido del proxy es demasiado grande",
+		entities.ErrProxyTooManyConnections:     "Demasiadas conexiones de proxy, cierra algunas e inténtalo de nuevo",
+		entities.ErrProxyUpgradeFailed:          "No se pudo abrir la conexión del proxy",
+		entities.ErrBotWebhookDisabled:          "El webhook del bot está desactivado",
+		entities.ErrBotWebhookInvalidSecret:     "Secreto del webhook del bot no válido",
+		entities.ErrBotWebhookHandleFailed:      "No se pudo procesar la actualización del bot",
+		entities.ErrRpcChainNotFound:            "La cadena no es compatible",
+		entities.ErrPriceUnavailable:            "El precio no está disponible, inténtalo más tarde",
+		entities.ErrPriceFiatNotSupported:       "La moneda fiduciaria no es compatible",
+		entities.ErrPriceAlertLimit:             "Has alcanzado el número máximo de alertas de precio",
+		entities.ErrPriceCoinNotSupported:       "La moneda no es compatible",
+		entities.ErrMiningNothingToClaim:        "Todavía no hay nada minado para reclamar",
+		entities.ErrNotEnoughPoints:             "No tienes suficientes puntos",
+		entities.ErrUpgradeMaxLevel:             "La mejora ya está al nivel máximo",
+		entities.ErrTapRejected:                 "Los toques fueron rechazados",
+		entities.ErrTapFlagged:                  "Los toques están suspendidos para tu cuenta",
+		entities.ErrBoosterNotForSale:           "El potenciador no está a la venta",
+	},
+}
@@ -0,0 +1,64 @@
+package i18n
+
+import "game-mining-server/entities"
+
+var ru = Catalog{
+	Messages: map[string]string{
+		MsgCmdStartDesc:          "старт",
+		MsgMenuWallet:            "💰Кошелёк",
+		MsgOpenWallet:            "Открыть кошелёк",
+		MsgStartCaption:          "Добро пожаловать в CodexField Wallet! 🚀\n\nВаш путь в мир EVM теперь у вас под рукой. Легко управляйте криптоактивами, работайте с DeFi-протоколами и исследуйте захватывающий мир Web3 прямо в Telegram.\n\nСледите за новыми функциями и обновлениями!",
+		MsgNotifyMomentLike:      "❤️ %s оценил(а) ваш момент",
+		MsgNotifyMomentComment:   "💬 %s прокомментировал(а) ваш момент: %s",
+		MsgNotifyMomentReward:    "🎁 %s отправил(а) вашему моменту %s очков",
+		MsgNotifyCheckinReminder: "⏰ Ежедневная отметка ждёт вас! Получите %s очков сейчас, чтобы сохранить серию из %s дн.",
+		MsgNotifyPriceAboveAlert: "🔔 %s поднялся выше %s, сейчас %s",
+		MsgNotifyPriceBelowAlert: "🔔 %s опустился ниже %s, сейчас %s",
+		MsgNotifyAchievement:     "🏅 Достижение получено: %s! Вы заработали %s очков",
+		MsgNotifySomeone:         "Кто-то",
+		MsgBroadcastUsage:        "Использование:\n/broadcast send <текст> - отправить текст всем пользователям, ответьте на фото, чтобы отправить его с текстом в подписи\n/broadcast status <id>\n/broadcast pause <id>\n/broadcast resume <id>\n/broadcast cancel <id>",
+		MsgBroadcastCreated:      "Рассылка #%s создана, доставка %s пользователям",
+		MsgBroadcastStatus:       "Рассылка #%s: %s\nДоставлено: %s, заблокировано: %s, ошибок: %s, всего: %s",
+		MsgBroadcastFailed:       "Ошибка операции рассылки: %s",
+	},
+	Errors: map[int]string{
+		entities.ErrTooManyRequests:             "Слишком много запросов, попробуйте позже",
+		entities.ErrUnknown:                     "Что-то пошло не так, попробуйте позже",
+		entities.ErrInvalidParams:               "Неверные параметры запроса",
+		entities.ErrInvalidAuthHeader:           "Ошибка авторизации, войдите снова",
+		entities.ErrInvalidInitData:             "Неверные данные инициализации Telegram",
+		entities.ErrParseInitDataFailed:         "Не удалось разобрать данные инициализации Telegram",
+		entities.ErrGenUserSessionFailed:        "Не удалось создать сессию, попробуйте снова",
+		entities.ErrUserNotFound:                "Пользователь не найден, войдите снова",
+		entities.ErrUserAuthExpired:             "Сессия истекла, войдите снова",
+		entities.ErrPermissionDenied:            "Недостаточно прав",
+		entities.ErrInternalDBInsertFailed:      "Не удалось сохранить данные, попробуйте снова",
+		entities.ErrInternalDBQueryFailed:       "Не удалось загрузить данные, попробуйте снова",
+		entities.ErrInternalDBUpdateFailed:      "Не удалось обновить данные, попробуйте снова",
+		entities.ErrInternalDBDeleteFailed:      "Не удалось удалить данные, попробуйте снова",
+		entities.ErrInternalGenerateTokenFailed: "Не удалось создать токен, попробуйте снова",
+		entities.ErrInternalCacheQueryFailed:    "Не удалось прочитать кэш, попробуйте снова",
+		entities.ErrProxyCreateRequestFailed:    "Не удалось создать прокси-запрос",
+		entities.ErrProxyRequestFailed:          "Ошибка прокси-запроса",
+		entities.ErrProxyParseResBodyFailed:     "Не удалось разобрать ответ прокси",
+		entities.ErrProxyReadResBodyFailed:      "Не удалось прочитать ответ прокси",
+		entities.ErrProxyUrlNotAllowed:          "Адрес прокси не разрешён",
+		entities.ErrProxyBodyTooLarge:           "Слишком большой объём данных прокси",
+		entities.ErrProxyTooManyConnections:     "Слишком много подключений прокси, закройте некоторые и попробуйте снова",
+		entities.ErrProxyUpgradeFailed:          "Не удалось открыть подключение прокси",
+		entities.ErrBotWebhookDisabled:          "Вебхук бота отключён",
+		entities.ErrBotWebhookInvalidSecret:     "Неверный секрет вебхука бота",
+		entities.ErrBotWebhookHandleFailed:      "Не удалось обработать обновление бота",
+		entities.ErrRpcChainNotFound:            "Сеть не поддерживается",
+		entities.ErrPriceUnavailable:            "Цена недоступна, попробуйте позже",
+		entities.ErrPriceFiatNotSupported:       "Фиатная валюта не поддерживается",
+		entities.ErrPriceAlertLimit:             "Достигнуто максимальное количество ценовых оповещений",
+		entities.ErrPriceCoinNotSupported:       "Монета не поддерживается",
+		entities.ErrMiningNothingToClaim:        "Пока нечего забрать из майнинга",
+		entities.ErrNotEnoughPoints:             "Недостаточно очков",
+		entities.ErrUpgradeMaxLevel:             "Улучшение уже на максимальном уровне",
+		entities.ErrTapRejected:                 "Нажатия отклонены",
+		entities.ErrTapFlagged:                  "Нажатия для вашего аккаунта приостановлены",
+		entities.ErrBoosterNotForSale:           "Бустер не продаётся",
+	},
+}
@@ -0,0 +1,64 @@
+package i18n
+
+import "game-mining-server/entities"
+
+var zh = Catalog{
+	Messages: map[string]string{
+		MsgCmdStartDesc:          "开始",
+		MsgMenuWallet:            "💰钱包",
+		MsgOpenWallet:            "打开钱包",
+		MsgStartCaption:          "欢迎使用 CodexField 钱包！🚀\n\n通往 EVM 世界的大门现已触手可及。在 Telegram 中即可轻松管理加密资产、使用 DeFi 协议，探索精彩的 Web3 世界。\n\n更多功能和更新敬请期待！",
+		MsgNotifyMomentLike:      "❤️ %s 赞了你的动态",
+		MsgNotifyMomentComment:   "💬 %s 评论了你的动态：%s",
+		MsgNotifyMomentReward:    "🎁 %s 打赏了你的动态 %s 积分",
+		MsgNotifyCheckinReminder: "⏰ 今日签到等你领取！立即领取 %s 积分，保持 %s 天连续签到。",
+		MsgNotifyPriceAboveAlert: "🔔 %s 已涨破 %s，当前 %s",
+		MsgNotifyPriceBelowAlert: "🔔 %s 已跌破 %s，当前 %s",
+		MsgNotifyAchievement:     "🏅 解锁成就：%s！获得 %s 积分",
+		MsgNotifySomeone:         "有人",
+		MsgBroadcastUsage:        "用法：\n/broadcast send <文本> - 向所有用户发送文本，回复一张图片可将文本作为图片说明一起发送\n/broadcast status <id>\n/broadcast pause <id>\n/broadcast resume <id>\n/broadcast cancel <id>",
+		MsgBroadcastCreated:      "广播 #%s 已创建，将发送给 %s 位用户",
+		MsgBroadcastStatus:       "广播 #%s：%s\n已送达：%s，已屏蔽：%s，失败：%s，总计：%s",
+		MsgBroadcastFailed:       "广播操作失败：%s",
+	},
+	Errors: map[int]string{
+		entities.ErrTooManyRequests:             "请求过于频繁，请稍后再试",
+		entities.ErrUnknown:                     "出错了，请稍后再试",
+		entities.ErrInvalidParams:               "请求参数无效",
+		entities.ErrInvalidAuthHeader:           "授权失败，请重新登录",
+		entities.ErrInvalidInitData:             "Telegram 初始化数据无效",
+		entities.ErrParseInitDataFailed:         "解析 Telegram 初始化数据失败",
+		entities.ErrGenUserSessionFailed:        "创建会话失败，请重试",
+		entities.ErrUserNotFound:                "用户不存在，请重新登录",
+		entities.ErrUserAuthExpired:             "会话已过期，请重新登录",
+		entities.ErrPermissionDenied:            "没有权限",
+		entities.ErrInternalDBInsertFailed:      "保存数据失败，请重试",
+		entities.ErrInternalDBQueryFailed:       "加载数据失败，请重试",
+		entities.ErrInternalDBUpdateFailed:      "更新数据失败，请重试",
+		entities.ErrInternalDBDeleteFailed:      "删除数据失败，请重试",
+		entities.ErrInternalGenerateTokenFailed: "生成令牌失败，请重试",
+		entities.ErrInternalCacheQueryFailed:    "读取缓存失败，请重试",
+		entities.ErrProxyCreateRequestFailed:    "创建代理请求失败",
+		entities.ErrProxyRequestFailed:          "代理请求失败",
+		entities.ErrProxyParseResBodyFailed:     "解析代理响应失败",
+		entities.ErrProxyReadResBodyFailed:      "读取代理响应失败",
+		entities.ErrProxyUrlNotAllowed:          "不允许代理该地址",
+		entities.ErrProxyBodyTooLarge:           "代理内容过大",
+		entities.ErrProxyTooManyConnections:     "代理连接过多，请关闭部分连接后重试",
+		entities.ErrProxyUpgradeFailed:          "打开代理连接失败",
+		entities.ErrBotWebhookDisabled:          "机器人 Webhook 未启用",
+		entities.ErrBotWebhookInvalidSecret:     "机器人 Webhook 密钥无效",
+		entities.ErrBotWebhookHandleFailed:      "处理机器人更新失败",
+		entities.ErrRpcChainNotFound:            "不支持该链",
+		entities.ErrPriceUnavailable:            "价格暂不可用，请稍后重试",
+		entities.ErrPriceFiatNotSupported:       "不支持该法币",
+		entities.ErrPriceAlertLimit:             "价格提醒数量已达上限",
+		entities.ErrPriceCoinNotSupported:       "不支持该币种",
+		entities.ErrMiningNothingToClaim:        "暂无可领取的挖矿收益",
+		entities.ErrNotEnoughPoints:             "积分不足",
+		entities.ErrUpgradeMaxLevel:             "升级已达最高等级",
+		entities.ErrTapRejected:                 "点击被拒绝",
+		entities.ErrTapFlagged:                  "你的账号已暂停点击",
+		entities.ErrBoosterNotForSale:           "该加速器不可购买",
+	},
+}
@@ -10,6 +10,14 @@ USE `{db_name}`;
 -- Table comments
 -- Table likes
 -- Table reward_logs
+-- Table notification_prefs
+-- Table broadcasts
+-- Table broadcast_deliveries
+-- Table price_alerts
+-- Table minings
+-- Table taps
+-- Table boosters
+-- Table achievements
 
 -- Table users (updated)
 CREATE TABLE IF NOT EXISTS `users`
@@ -29,7 +37,8 @@ CREATE TABLE IF NOT EXISTS `users`
     PRIMARY KEY (`id`)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
 
--- Table checkins
+-- Table checkins (updated)
+-- CREATED_UID is added to an existing table on start, see addedIndexes in dbs/service.go
 CREATE TABLE IF NOT EXISTS `checkins`
 (
     `id`                    VARCHAR(255) NOT NULL,
@@ -40,6 +49,7 @@ CREATE TABLE IF NOT EXISTS `checkins`
     `reward_point`          BIGINT       NOT NULL DEFAULT 0,
     `status`                INT          NOT NULL DEFAULT 0,
     INDEX UID (uid),
+    INDEX CREATED_UID (created_at, uid),
     PRIMARY KEY (`id`)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
 
@@ -58,7 +68,9 @@ CREATE TABLE IF NOT EXISTS `tasks`
     PRIMARY KEY (`id`)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
 
--- Table points
+-- Table points (updated)
+-- total_mining_point_value, total_tap_point_value and total_achievement_point_value are added to an existing table on
+-- start, see addedColumns in dbs/service.go
 CREATE TABLE IF NOT EXISTS `points`
 (
     `uid`                        BIGINT       NOT NULL,
@@ -68,6 +80,9 @@ CREATE TABLE IF NOT EXISTS `points`
     `total_wallet_point_value`   BIGINT       NOT NULL DEFAULT 0,
     `last_invite_point_level`    BIGINT       NOT NULL DEFAULT 0,
     `total_invite_point_value`   BIGINT       NOT NULL DEFAULT 0,
+    `total_mining_point_value`   BIGINT       NOT NULL DEFAULT 0,
+    `total_tap_point_value`      BIGINT       NOT NULL DEFAULT 0,
+    `total_achievement_point_value` BIGINT    NOT NULL DEFAULT 0,
     `total_point_value`          BIGINT       NOT NULL DEFAULT 0,
     PRIMARY KEY (`uid`)
 ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -124,4 +139,131 @@ CREATE TABLE IF NOT EXISTS `reward_logs` (
     INDEX `idx_to_user` (`to_user_id`),
     INDEX `idx_moment` (`moment_id`),
     PRIMARY KEY (`id`)
-) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
\ No newline at end of file
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- Table notification_prefs
+CREATE TABLE IF NOT EXISTS `notification_prefs` (
+    `uid`              BIGINT       NOT NULL,
+    `created_at`       BIGINT       NOT NULL,
+    `updated_at`       BIGINT       NOT NULL,
+    `moment_like`      BOOL         NOT NULL DEFAULT true,
+    `moment_comment`   BOOL         NOT NULL DEFAULT true,
+    `moment_reward`    BOOL         NOT NULL DEFAULT true,
+    `checkin_reminder` BOOL         NOT NULL DEFAULT true,
+    PRIMARY KEY (`uid`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- Table broadcasts
+CREATE TABLE IF NOT EXISTS `broadcasts` (
+    `id`            BIGINT        NOT NULL AUTO_INCREMENT,
+    `created_at`    BIGINT        NOT NULL,
+    `updated_at`    BIGINT        NOT NULL,
+    `created_by`    BIGINT        NOT NULL,
+    `text`          TEXT          NOT NULL,
+    `photo`         VARCHAR(1024),
+    `button_text`   VARCHAR(255),
+    `button_url`    VARCHAR(1024),
+    `status`        INT           NOT NULL DEFAULT 0,
+    `last_uid`      BIGINT        NOT NULL DEFAULT 0,
+    `total`         BIGINT        NOT NULL DEFAULT 0,
+    `delivered`     BIGINT        NOT NULL DEFAULT 0,
+    `blocked`       BIGINT        NOT NULL DEFAULT 0,
+    `failed`        BIGINT        NOT NULL DEFAULT 0,
+    INDEX STATUS (status),
+    PRIMARY KEY (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- Table broadcast_deliveries
+CREATE TABLE IF NOT EXISTS `broadcast_deliveries` (
+    `broadcast_id`  BIGINT        NOT NULL,
+    `uid`           BIGINT        NOT NULL,
+    `created_at`    BIGINT        NOT NULL,
+    `status`        INT           NOT NULL DEFAULT 0,
+    `error`         VARCHAR(255),
+    INDEX UID (uid),
+    PRIMARY KEY (`broadcast_id`, `uid`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- Table price_alerts
+CREATE TABLE IF NOT EXISTS `price_alerts` (
+    `id`            BIGINT        NOT NULL AUTO_INCREMENT,
+    `created_at`    BIGINT        NOT NULL,
+    `updated_at`    BIGINT        NOT NULL,
+    `uid`           BIGINT        NOT NULL,
+    `coin_symbol`   VARCHAR(32)   NOT NULL,
+    `fiat_symbol`   VARCHAR(8)    NOT NULL,
+    `direction`     INT           NOT NULL,
+    `target_price`  DOUBLE        NOT NULL,
+    `recurring`     BOOL          NOT NULL DEFAULT false,
+    `armed`         BOOL          NOT NULL DEFAULT true,
+    `status`        INT           NOT NULL DEFAULT 0,
+    `trigger_count` BIGINT        NOT NULL DEFAULT 0,
+    `triggered_at`  BIGINT        NOT NULL DEFAULT 0,
+    INDEX UID (uid),
+    INDEX STATUS_COIN (status, coin_symbol),
+    PRIMARY KEY (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- Table minings
+CREATE TABLE IF NOT EXISTS `minings` (
+    `uid`                      BIGINT        NOT NULL,
+    `created_at`               BIGINT        NOT NULL,
+    `updated_at`               BIGINT        NOT NULL,
+    `rate_per_hour`            BIGINT        NOT NULL,
+    `capacity`                 BIGINT        NOT NULL,
+    `auto_claim_sec`           BIGINT        NOT NULL DEFAULT 0,
+    `rate_level`               INT           NOT NULL DEFAULT 0,
+    `capacity_level`           INT           NOT NULL DEFAULT 0,
+    `auto_claim_level`         INT           NOT NULL DEFAULT 0,
+    `extra_capacity`           BIGINT        NOT NULL DEFAULT 0,
+    `last_claimed_at`          BIGINT        NOT NULL,
+    `total_mined_point_value`  BIGINT        NOT NULL DEFAULT 0,
+    PRIMARY KEY (`uid`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- Table taps
+CREATE TABLE IF NOT EXISTS `taps` (
+    `uid`                BIGINT        NOT NULL,
+    `created_at`         BIGINT        NOT NULL,
+    `updated_at`         BIGINT        NOT NULL,
+    `energy`             BIGINT        NOT NULL,
+    `energy_updated_at`  BIGINT        NOT NULL,
+    `last_ended_at`      BIGINT        NOT NULL DEFAULT 0,
+    `total_taps`         BIGINT        NOT NULL DEFAULT 0,
+    `total_point_value`  BIGINT        NOT NULL DEFAULT 0,
+    `accepted_batches`   BIGINT        NOT NULL DEFAULT 0,
+    `rejected_batches`   BIGINT        NOT NULL DEFAULT 0,
+    `window_rejects`     BIGINT        NOT NULL DEFAULT 0,
+    `window_started_at`  BIGINT        NOT NULL DEFAULT 0,
+    `clamped_taps`       BIGINT        NOT NULL DEFAULT 0,
+    `flagged`            BOOL          NOT NULL DEFAULT false,
+    `last_reject_reason` VARCHAR(255),
+    PRIMARY KEY (`uid`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- Table boosters
+CREATE TABLE IF NOT EXISTS `boosters` (
+    `id`            BIGINT        NOT NULL AUTO_INCREMENT,
+    `created_at`    BIGINT        NOT NULL,
+    `updated_at`    BIGINT        NOT NULL,
+    `uid`           BIGINT        NOT NULL,
+    `booster_id`    VARCHAR(64)   NOT NULL,
+    `type`          VARCHAR(32)   NOT NULL,
+    `multiplier`    DOUBLE        NOT NULL DEFAULT 1,
+    `source`        VARCHAR(32)   NOT NULL,
+    `expires_at`    BIGINT        NOT NULL,
+    INDEX UID_EXPIRES (uid, expires_at),
+    PRIMARY KEY (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+
+-- Table achievements
+CREATE TABLE IF NOT EXISTS `achievements` (
+    `id`              BIGINT        NOT NULL AUTO_INCREMENT,
+    `created_at`      BIGINT        NOT NULL,
+    `uid`             BIGINT        NOT NULL,
+    `achievement_id`  VARCHAR(64)   NOT NULL,
+    `name`            VARCHAR(255)  NOT NULL,
+    `reward_point`    BIGINT        NOT NULL DEFAULT 0,
+    UNIQUE INDEX UID_ACHIEVEMENT (uid, achievement_id),
+    PRIMARY KEY (`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"game-mining-server/app"
 	"game-mining-server/handlers"
+	"game-mining-server/notifications"
 	"game-mining-server/routers"
 	"log"
 	"os"
@@ -31,8 +32,12 @@ func main() {
 		panic(fmt.Errorf("bot server run failed: %s", e2))
 	}
 
+	notifications.Run(app.Bot(), app.Config().Notify)
+
+	app.Price().Run()
+
 	// start http server
-	if e3 := routers.InitAndRun(app.Config().Basic); e3 != nil {
+	if e3 := routers.InitAndRun(app.Config()); e3 != nil {
 		panic(fmt.Errorf("http server run failed: %s", e3))
 	}
 }
@@ -0,0 +1,84 @@
+package notifications
+
+import (
+	"context"
+	"game-mining-server/app"
+	"game-mining-server/caches"
+	"github.com/go-redsync/redsync/v4"
+	"log"
+	"time"
+)
+
+const defaultPriceAlertInterval = 30 * time.Second
+
+// schedulePriceAlerts keep prices of coins with active alerts refreshing, alerts are evaluated by the refresh listener.
+// The lock is not released after refresh, so only one replica refreshes in each interval
+func schedulePriceAlerts(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		mutex := app.Cache().RedSyncLock.NewMutex(caches.GenLockCacheKey("priceAlerts"),
+			redsync.WithExpiry(max(interval-time.Second, time.Second)), redsync.WithTries(1))
+		if e0 := mutex.Lock(); e0 != nil {
+			continue
+		}
+		symbols, e1 := app.DB().PriceAlertFindActiveSymbols()
+		if e1 != nil {
+			log.Printf("Price alert find symbols failed: %s\n", e1)
+			continue
+		}
+		if len(symbols) == 0 {
+			continue
+		}
+		if _, e2 := app.Price().GetCachedUSDPrices(context.Background(), symbols); e2 != nil {
+			log.Printf("Price alert refresh %d coins failed: %s\n", len(symbols), e2)
+		}
+	}
+}
+
+// evaluatePriceAlerts trigger armed alerts whose target is reached by refreshed usd prices, and re-arm active
+// alerts once price crosses back
+func evaluatePriceAlerts(usdPrices map[string]float64) {
+	symbols := make([]string, 0, len(usdPrices))
+	for symbol := range usdPrices {
+		symbols = append(symbols, symbol)
+	}
+	if len(symbols) == 0 {
+		return
+	}
+	alerts, e0 := app.DB().PriceAlertFindActiveBySymbols(symbols)
+	if e0 != nil {
+		log.Printf("Price alert find alerts failed: %s\n", e0)
+		return
+	}
+
+	for _, alert := range alerts {
+		rate, ok := app.Price().Fx.Rate(alert.FiatSymbol)
+		if !ok {
+			continue
+		}
+		price := usdPrices[alert.CoinSymbol] * rate
+		if !alert.IsReached(price) {
+			// active alerts are disarmed after a recurring trigger, or when created past their target
+			if !alert.Armed {
+				if e1 := app.DB().PriceAlertRearm(alert); e1 != nil {
+					log.Printf("Price alert %d re-arm failed: %s\n", alert.Id, e1)
+				}
+			}
+			continue
+		}
+		if !alert.Armed {
+			continue
+		}
+		// only the replica which disarms the alert notifies
+		if triggered, e2 := app.DB().PriceAlertTrigger(alert); e2 != nil || !triggered {
+			if e2 != nil {
+				log.Printf("Price alert %d trigger failed: %s\n", alert.Id, e2)
+			}
+			continue
+		}
+		if e3 := NotifyPriceAlert(alert, price); e3 != nil {
+			log.Printf("Price alert %d enqueue failed: %s\n", alert.Id, e3)
+		}
+	}
+}
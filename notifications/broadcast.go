@@ -0,0 +1,152 @@
+package notifications
+
+import (
+	"game-mining-server/app"
+	"game-mining-server/caches"
+	"game-mining-server/configs"
+	"game-mining-server/dbs"
+	"github.com/go-redsync/redsync/v4"
+	"github.com/mymmrac/telego"
+	tu "github.com/mymmrac/telego/telegoutil"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	broadcastBatchSize     = 100
+	broadcastPollInterval  = 5 * time.Second
+	broadcastLockExpiry    = time.Minute
+	broadcastMaxSendTries  = 3
+	broadcastMaxRetryAfter = 30 * time.Second // longer waits asked by telegram fail the delivery, so lock is kept
+	broadcastErrorMaxBytes = 255
+)
+
+// runningBroadcasts ids of broadcasts being delivered by this replica
+var runningBroadcasts sync.Map
+
+// scheduleBroadcasts poll running broadcasts and deliver each in its own goroutine, so a large broadcast does not hold
+// up others. Each broadcast is delivered by only one replica
+func scheduleBroadcasts(bot *telego.Bot) {
+	ticker := time.NewTicker(broadcastPollInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		broadcasts, e0 := app.DB().BroadcastFindRunning()
+		if e0 != nil {
+			log.Printf("Broadcast find running failed: %s\n", e0)
+			continue
+		}
+		for _, broadcast := range broadcasts {
+			if _, running := runningBroadcasts.LoadOrStore(broadcast.Id, true); running {
+				continue
+			}
+			go func(id int64) {
+				defer runningBroadcasts.Delete(id)
+				runBroadcast(bot, id)
+			}(broadcast.Id)
+		}
+	}
+}
+
+// runBroadcast deliver a broadcast batch by batch until it is finished, paused or cancelled. The lock is extended
+// before every send, if it is lost, deliveries so far are saved and the broadcast is left to the new holder
+func runBroadcast(bot *telego.Bot, id int64) {
+	mutex := app.Cache().RedSyncLock.NewMutex(caches.GenLockCacheKey("broadcast:"+strconv.FormatInt(id, 10)),
+		redsync.WithExpiry(broadcastLockExpiry), redsync.WithTries(1))
+	if e0 := mutex.Lock(); e0 != nil {
+		return // other replica is delivering it
+	}
+	defer func() {
+		_, _ = mutex.Unlock()
+	}()
+
+	for {
+		// reload every batch to see pause or cancel
+		broadcast, e1 := app.DB().BroadcastFindById(id)
+		if e1 != nil || broadcast.Status != configs.BroadcastStatusRunning {
+			return
+		}
+		uids, e2 := app.DB().UserFindIdsAfter(broadcast.LastUid, broadcastBatchSize)
+		if e2 != nil {
+			log.Printf("Broadcast %d find users failed: %s\n", id, e2)
+			return
+		}
+
+		deliveries := make([]*dbs.BroadcastDelivery, 0, len(uids))
+		lastUid := broadcast.LastUid
+		locked := true
+		for _, uid := range uids {
+			delivery := deliverBroadcast(bot, broadcast, uid, func() bool {
+				ok, e3 := mutex.Extend()
+				return ok && e3 == nil
+			})
+			if delivery == nil {
+				locked = false
+				break
+			}
+			deliveries = append(deliveries, delivery)
+			lastUid = uid
+		}
+		finished := locked && len(uids) < broadcastBatchSize
+		if e4 := app.DB().BroadcastSaveBatch(id, broadcast.LastUid, lastUid, deliveries, finished); e4 != nil {
+			log.Printf("Broadcast %d save batch failed: %s\n", id, e4)
+			return
+		}
+		if !locked {
+			log.Printf("Broadcast %d lock lost, stop delivering\n", id)
+			return
+		}
+		if finished {
+			log.Printf("Broadcast %d finished\n", id)
+			return
+		}
+	}
+}
+
+// deliverBroadcast send broadcast to a user, wait and retry if telegram asks to.
+// keepLock is called before every try, nil is returned without sending if the lock is lost
+func deliverBroadcast(bot *telego.Bot, broadcast *dbs.Broadcast, uid int64, keepLock func() bool) *dbs.BroadcastDelivery {
+	delivery := &dbs.BroadcastDelivery{BroadcastId: broadcast.Id, Uid: uid, Status: configs.BroadcastDeliveryDelivered}
+	var err error
+	for i := 0; i < broadcastMaxSendTries; i++ {
+		if !keepLock() {
+			return nil
+		}
+		if err = sendBroadcast(bot, broadcast, uid); err == nil {
+			return delivery
+		}
+		retryAfter, blocked := ParseSendError(err)
+		if blocked {
+			delivery.Status = configs.BroadcastDeliveryBlocked
+			break
+		}
+		if retryAfter <= 0 || retryAfter > broadcastMaxRetryAfter {
+			delivery.Status = configs.BroadcastDeliveryFailed
+			break
+		}
+		delivery.Status = configs.BroadcastDeliveryFailed
+		time.Sleep(retryAfter)
+	}
+	delivery.Error = err.Error()
+	if len(delivery.Error) > broadcastErrorMaxBytes {
+		delivery.Error = delivery.Error[:broadcastErrorMaxBytes]
+	}
+	return delivery
+}
+
+func sendBroadcast(bot *telego.Bot, broadcast *dbs.Broadcast, uid int64) error {
+	var markup telego.ReplyMarkup
+	if broadcast.ButtonText != "" && broadcast.ButtonUrl != "" {
+		markup = tu.InlineKeyboard(tu.InlineKeyboardRow(tu.InlineKeyboardButton(broadcast.ButtonText).WithURL(broadcast.ButtonUrl)))
+	}
+	if broadcast.Photo == "" {
+		return SendMessage(bot, &telego.SendMessageParams{ChatID: tu.ID(uid), Text: broadcast.Text, ReplyMarkup: markup})
+	}
+	photo := tu.FileFromID(broadcast.Photo)
+	if strings.HasPrefix(broadcast.Photo, "http://") || strings.HasPrefix(broadcast.Photo, "https://") {
+		photo = tu.FileFromURL(broadcast.Photo)
+	}
+	return SendPhoto(bot, &telego.SendPhotoParams{ChatID: tu.ID(uid), Photo: photo, Caption: broadcast.Text, ReplyMarkup: markup})
+}
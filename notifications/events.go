@@ -0,0 +1,102 @@
+package notifications
+
+import (
+	"game-mining-server/app"
+	"game-mining-server/caches"
+	"game-mining-server/configs"
+	"game-mining-server/dbs"
+	"log"
+	"math"
+	"strconv"
+)
+
+const commentSnippetLen = 100
+
+// momentNotifyDedupeSec seconds a user's action of a type on a moment is notified at most once, e.g. like toggling
+var momentNotifyDedupeSec = map[string]int{
+	configs.NotifyTypeMomentLike: 3600,
+}
+
+// actorName return display name of a user, empty if user has no username
+func actorName(uid int64) string {
+	user, e := caches.UserFindByIdCached(app.Cache(), app.DB(), uid, app.Config().Basic.SessionExpiresSec)
+	if e != nil || user == nil || user.Username == "" {
+		return ""
+	}
+	return "@" + user.Username
+}
+
+// notifyMomentOwner enqueue a notification to moment owner in background, actions on own moment are not notified
+func notifyMomentOwner(momentId int64, fromUid int64, notifyType string, args ...string) {
+	go enqueueMomentOwner(momentId, fromUid, notifyType, args...)
+}
+
+func enqueueMomentOwner(momentId int64, fromUid int64, notifyType string, args ...string) {
+	moment, e0 := app.DB().MomentFindById(momentId)
+	if e0 != nil {
+		log.Printf("Notify %s find moment %d failed: %s\n", notifyType, momentId, e0)
+		return
+	}
+	if moment.UserId == fromUid {
+		return
+	}
+	if dedupeSec := momentNotifyDedupeSec[notifyType]; dedupeSec > 0 {
+		key := caches.GenMomentNotifyDedupeCacheKey(notifyType, momentId, fromUid)
+		if first, e1 := app.Cache().SetStringIfNotExists(key, "1", dedupeSec); e1 != nil {
+			log.Printf("Notify %s dedupe failed: %s\n", notifyType, e1)
+			return
+		} else if !first {
+			return
+		}
+	}
+	if e2 := Enqueue(moment.UserId, notifyType, append([]string{actorName(fromUid)}, args...)...); e2 != nil {
+		log.Printf("Notify %s enqueue failed: %s\n", notifyType, e2)
+	}
+}
+
+// NotifyMomentLiked notify moment owner that someone liked the moment, once an hour per user and moment
+func NotifyMomentLiked(momentId int64, fromUid int64) {
+	notifyMomentOwner(momentId, fromUid, configs.NotifyTypeMomentLike)
+}
+
+// NotifyMomentCommented notify moment owner that someone commented on the moment
+func NotifyMomentCommented(momentId int64, fromUid int64, content string) {
+	if runes := []rune(content); len(runes) > commentSnippetLen {
+		content = string(runes[:commentSnippetLen]) + "…"
+	}
+	notifyMomentOwner(momentId, fromUid, configs.NotifyTypeMomentComment, content)
+}
+
+// NotifyMomentRewarded notify moment owner that someone tipped the moment
+func NotifyMomentRewarded(momentId int64, fromUid int64, amount int) {
+	notifyMomentOwner(momentId, fromUid, configs.NotifyTypeMomentReward, strconv.Itoa(amount))
+}
+
+// NotifyCheckinReminder notify user that a daily checkin is waiting to be claimed
+func NotifyCheckinReminder(uid int64, rewardPoint int64, continuousDays int) error {
+	return Enqueue(uid, configs.NotifyTypeCheckinReminder, strconv.FormatInt(rewardPoint, 10), strconv.Itoa(continuousDays))
+}
+
+// NotifyPriceAlert notify alert owner that price reached the target
+func NotifyPriceAlert(alert *dbs.PriceAlert, price float64) error {
+	notifyType := configs.NotifyTypePriceAboveAlert
+	if alert.Direction == configs.PriceAlertDirectionBelow {
+		notifyType = configs.NotifyTypePriceBelowAlert
+	}
+	return Enqueue(alert.Uid, notifyType, alert.CoinSymbol,
+		formatPrice(alert.TargetPrice)+" "+alert.FiatSymbol, formatPrice(price)+" "+alert.FiatSymbol)
+}
+
+// NotifyAchievement notify user that a badge is awarded
+func NotifyAchievement(achievement *dbs.Achievement) error {
+	return Enqueue(achievement.Uid, configs.NotifyTypeAchievement, achievement.Name, strconv.FormatInt(achievement.RewardPoint, 10))
+}
+
+// formatPrice keep 2 decimals for prices above 1, and 4 significant digits for smaller ones, e.g. 0.0001234
+func formatPrice(price float64) string {
+	decimals := 2
+	if price > 0 && price < 1 {
+		decimals = int(-math.Floor(math.Log10(price))) + 3
+	}
+	return strconv.FormatFloat(price, 'f', decimals, 64)
+}
@@ -0,0 +1,65 @@
+package notifications
+
+import (
+	"game-mining-server/app"
+	"game-mining-server/caches"
+	"game-mining-server/configs"
+	"game-mining-server/utils"
+	"github.com/go-redsync/redsync/v4"
+	"log"
+	"time"
+)
+
+const (
+	defaultCheckinReminderInterval = 10 * time.Minute
+	defaultCheckinReminderLead     = 4 * time.Hour
+	checkinReminderBatchSize       = 500
+	checkinReminderMarkExpiresSec  = 2 * 24 * 3600
+)
+
+// scheduleCheckinReminders scan users whose checkin streak is about to break and remind them,
+// the lock is not released after scan, so only one replica scans in each interval
+func scheduleCheckinReminders(interval time.Duration, lead time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		mutex := app.Cache().RedSyncLock.NewMutex(caches.GenLockCacheKey("checkinReminder"),
+			redsync.WithExpiry(max(interval-time.Second, time.Second)), redsync.WithTries(1))
+		if e0 := mutex.Lock(); e0 != nil {
+			continue
+		}
+		remindCheckins(lead)
+	}
+}
+
+// remindCheckins enqueue reminders for all users whose streak breaks within lead duration, once per day for each user
+func remindCheckins(lead time.Duration) {
+	basicConfig := app.Config().Basic
+	date := time.Now().UTC().Format(time.DateOnly)
+	afterUid, reminded := int64(0), 0
+	for {
+		checkins, e0 := app.DB().CheckinFindStreaksAboutToBreak(basicConfig, lead, afterUid, checkinReminderBatchSize)
+		if e0 != nil {
+			log.Printf("Checkin reminder find streaks failed: %s\n", e0)
+			return
+		}
+		for _, checkin := range checkins {
+			afterUid = checkin.Uid
+			if ok, e1 := app.Cache().SetStringIfNotExists(caches.GenCheckinReminderCacheKey(date, checkin.Uid), "1", checkinReminderMarkExpiresSec); e1 != nil || !ok {
+				continue // already reminded today
+			}
+			nextPoint := utils.CalPointForDailyCheckin(configs.CheckinBaseRewardPoint, checkin.ContinuousDays+1)
+			if e2 := NotifyCheckinReminder(checkin.Uid, nextPoint, checkin.ContinuousDays); e2 != nil {
+				log.Printf("Checkin reminder enqueue failed: %s\n", e2)
+				continue
+			}
+			reminded++
+		}
+		if len(checkins) < checkinReminderBatchSize {
+			break
+		}
+	}
+	if reminded > 0 {
+		log.Printf("Checkin reminder enqueued %d reminders\n", reminded)
+	}
+}
@@ -0,0 +1,70 @@
+package notifications
+
+import (
+	"context"
+	"errors"
+	"game-mining-server/app"
+	"game-mining-server/caches"
+	"github.com/go-redis/redis_rate/v10"
+	"github.com/mymmrac/telego"
+	"github.com/mymmrac/telego/telegoapi"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultGlobalRatePerSec = 25 // telegram allows about 30 messages per second for a bot, keep some margin
+	chatRatePerSec          = 1  // telegram allows about 1 message per second in a chat
+)
+
+var globalRatePerSec = defaultGlobalRatePerSec
+
+// waitRateLimit block until the rate limiter of key allows one more message
+func waitRateLimit(key string, limit redis_rate.Limit) {
+	for {
+		res, err := app.Cache().RateLimiter.Allow(context.Background(), key, limit)
+		if err != nil || res.Allowed > 0 {
+			return // cache failure should not block delivery forever
+		}
+		time.Sleep(res.RetryAfter)
+	}
+}
+
+// waitSendLimit wait for both global bot limit and chat limit, limits are shared by all replicas by Redis
+func waitSendLimit(chatId int64) {
+	waitRateLimit(caches.GenRateLimitCacheKey("bot", "global"), redis_rate.PerSecond(globalRatePerSec))
+	waitRateLimit(caches.GenRateLimitCacheKey("bot", strconv.FormatInt(chatId, 10)), redis_rate.PerSecond(chatRatePerSec))
+}
+
+// SendMessage send a text message to chat and respect telegram rate limits
+func SendMessage(bot *telego.Bot, params *telego.SendMessageParams) error {
+	waitSendLimit(params.ChatID.ID)
+	_, err := bot.SendMessage(params)
+	return err
+}
+
+// SendPhoto send a photo message to chat and respect telegram rate limits
+func SendPhoto(bot *telego.Bot, params *telego.SendPhotoParams) error {
+	waitSendLimit(params.ChatID.ID)
+	_, err := bot.SendPhoto(params)
+	return err
+}
+
+// ParseSendError parse telegram send error, return the duration telegram asks to wait before retry,
+// and whether the user can never receive messages (blocked the bot, deactivated or never started the bot)
+func ParseSendError(err error) (retryAfter time.Duration, blocked bool) {
+	var apiErr *telegoapi.Error
+	if !errors.As(err, &apiErr) {
+		return 0, false
+	}
+	if apiErr.ErrorCode == http.StatusForbidden ||
+		(apiErr.ErrorCode == http.StatusBadRequest && strings.Contains(apiErr.Description, "chat not found")) {
+		return 0, true
+	}
+	if apiErr.Parameters != nil && apiErr.Parameters.RetryAfter > 0 {
+		return time.Duration(apiErr.Parameters.RetryAfter) * time.Second, false
+	}
+	return 0, false
+}
@@ -0,0 +1,271 @@
+package notifications
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"game-mining-server/app"
+	"game-mining-server/caches"
+	"game-mining-server/configs"
+	"game-mining-server/dbs"
+	"game-mining-server/i18n"
+	"github.com/google/uuid"
+	"github.com/mymmrac/telego"
+	tu "github.com/mymmrac/telego/telegoutil"
+	"github.com/redis/go-redis/v9"
+	"log"
+	"math"
+	"time"
+)
+
+const (
+	defaultWorkers    = 2
+	defaultMaxRetries = 5
+	retryBaseDelay    = 5 * time.Second
+	retryMaxDelay     = 10 * time.Minute
+
+	workerHeartbeatInterval = 10 * time.Second
+	workerAliveExpirySec    = 30 // a worker without heartbeat for this long is dead, its processing jobs are recovered
+)
+
+// Job a notification waiting in queue to be delivered
+type Job struct {
+	Id       string   `json:"id"`       // unique id, generated by uuid4
+	Uid      int64    `json:"uid"`      // receiver user id, which is also the private chat id with bot
+	Type     string   `json:"type"`     // notification type: momentLike, momentComment, momentReward, checkinReminder, priceAboveAlert, priceBelowAlert, achievement
+	Args     []string `json:"args"`     // template args, empty arg is rendered as "someone"
+	Attempts int      `json:"attempts"` // delivery attempts
+}
+
+// templates notification type -> i18n message key
+var templates = map[string]string{
+	configs.NotifyTypeMomentLike:      i18n.MsgNotifyMomentLike,
+	configs.NotifyTypeMomentComment:   i18n.MsgNotifyMomentComment,
+	configs.NotifyTypeMomentReward:    i18n.MsgNotifyMomentReward,
+	configs.NotifyTypeCheckinReminder: i18n.MsgNotifyCheckinReminder,
+	configs.NotifyTypePriceAboveAlert: i18n.MsgNotifyPriceAboveAlert,
+	configs.NotifyTypePriceBelowAlert: i18n.MsgNotifyPriceBelowAlert,
+	configs.NotifyTypeAchievement:     i18n.MsgNotifyAchievement,
+}
+
+var maxRetries = defaultMaxRetries
+
+// Enqueue push a notification to queue, it will be delivered by workers of any replica
+func Enqueue(uid int64, notifyType string, args ...string) error {
+	if app.Bot() == nil {
+		return nil // bot disabled, nobody will consume the queue
+	}
+	if _, ok := templates[notifyType]; !ok {
+		return errors.New("unknown notification type: " + notifyType)
+	}
+	return pushJob(&Job{Id: uuid.New().String(), Uid: uid, Type: notifyType, Args: args})
+}
+
+func pushJob(job *Job) error {
+	raw, e0 := json.Marshal(job)
+	if e0 != nil {
+		return e0
+	}
+	return app.Cache().ListPush(caches.GenNotifyQueueCacheKey(), string(raw))
+}
+
+// Run start notification workers and retry scheduler in background
+func Run(bot *telego.Bot, config *configs.NotifyConfig) {
+	if bot == nil {
+		log.Println("Not run notification workers")
+		return
+	}
+	workers := defaultWorkers
+	reminderInterval, reminderLead := defaultCheckinReminderInterval, defaultCheckinReminderLead
+	alertInterval := defaultPriceAlertInterval
+	if config != nil {
+		if config.Workers > 0 {
+			workers = config.Workers
+		}
+		if config.MaxRetries > 0 {
+			maxRetries = config.MaxRetries
+		}
+		if config.GlobalRatePerSec > 0 {
+			globalRatePerSec = config.GlobalRatePerSec
+		}
+		if config.CheckinReminderIntervalSec > 0 {
+			reminderInterval = time.Duration(config.CheckinReminderIntervalSec) * time.Second
+		}
+		if config.CheckinReminderLeadSec > 0 {
+			reminderLead = time.Duration(config.CheckinReminderLeadSec) * time.Second
+		}
+		if config.PriceAlertIntervalSec > 0 {
+			alertInterval = time.Duration(config.PriceAlertIntervalSec) * time.Second
+		}
+	}
+	instanceId := uuid.New().String()
+	for i := 0; i < workers; i++ {
+		go consume(bot, fmt.Sprintf("%s-%d", instanceId, i))
+	}
+	go scheduleRetries()
+	go scheduleRecovery()
+	go scheduleBroadcasts(bot)
+	go scheduleCheckinReminders(reminderInterval, reminderLead)
+	app.Price().OnRefresh(evaluatePriceAlerts)
+	go schedulePriceAlerts(alertInterval)
+	log.Printf("Run %d notification workers\n", workers)
+}
+
+// consume move jobs from queue to worker's processing list and deliver them. A job is removed from processing list
+// only after it is delivered or scheduled to retry, so jobs of a worker that dies mid-send are recovered
+func consume(bot *telego.Bot, workerId string) {
+	processingKey := caches.GenNotifyProcessingCacheKey(workerId)
+	// alive before registered, so recovery never takes a new worker as dead
+	_ = app.Cache().SetString(caches.GenNotifyWorkerAliveCacheKey(workerId), "1", workerAliveExpirySec)
+	if e0 := app.Cache().SSetAdd(caches.GenNotifyWorkersCacheKey(), workerId); e0 != nil {
+		log.Printf("Notification worker %s register failed: %s\n", workerId, e0)
+	}
+	go heartbeat(workerId)
+	for {
+		raw, e1 := app.Cache().ListBlockMove(caches.GenNotifyQueueCacheKey(), processingKey, 5*time.Second)
+		if e1 != nil {
+			if !errors.Is(e1, redis.Nil) {
+				log.Printf("Notification queue pop failed: %s\n", e1)
+				time.Sleep(time.Second)
+			}
+			continue
+		}
+		var job Job
+		if e2 := json.Unmarshal([]byte(raw), &job); e2 != nil {
+			log.Printf("Notification job decode failed: %s, %s\n", e2, raw)
+		} else if e3 := deliver(bot, &job); e3 != nil {
+			retryLater(&job, e3)
+		}
+		if e4 := app.Cache().ListRemove(processingKey, raw); e4 != nil {
+			log.Printf("Notification job %s remove from processing failed: %s\n", job.Id, e4)
+		}
+	}
+}
+
+// heartbeat keep a worker alive, so its processing jobs are not recovered by others
+func heartbeat(workerId string) {
+	ticker := time.NewTicker(workerHeartbeatInterval)
+	defer ticker.Stop()
+	for ; ; <-ticker.C {
+		_ = app.Cache().SetString(caches.GenNotifyWorkerAliveCacheKey(workerId), "1", workerAliveExpirySec)
+	}
+}
+
+// scheduleRecovery move processing jobs of dead workers back to queue
+func scheduleRecovery() {
+	ticker := time.NewTicker(workerAliveExpirySec * time.Second)
+	defer ticker.Stop()
+	for range ticker.C {
+		workerIds, e0 := app.Cache().SSetMembers(caches.GenNotifyWorkersCacheKey())
+		if e0 != nil {
+			continue
+		}
+		for _, workerId := range workerIds {
+			if _, e1 := app.Cache().GetString(caches.GenNotifyWorkerAliveCacheKey(workerId)); !errors.Is(e1, redis.Nil) {
+				continue
+			}
+			recovered := 0
+			for {
+				if _, e2 := app.Cache().ListMove(caches.GenNotifyProcessingCacheKey(workerId), caches.GenNotifyQueueCacheKey()); e2 != nil {
+					break
+				}
+				recovered++
+			}
+			_ = app.Cache().SSetDel(caches.GenNotifyWorkersCacheKey(), workerId)
+			if recovered > 0 {
+				log.Printf("Notification recovered %d jobs of dead worker %s\n", recovered, workerId)
+			}
+		}
+	}
+}
+
+// scheduleRetries move due retry jobs back to queue
+func scheduleRetries() {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+	for range ticker.C {
+		raws, e0 := app.Cache().ZSetPopByScore(caches.GenNotifyRetryCacheKey(), float64(time.Now().UnixMilli()), 100)
+		if e0 != nil {
+			continue
+		}
+		for _, raw := range raws {
+			_ = app.Cache().ListPush(caches.GenNotifyQueueCacheKey(), raw)
+		}
+	}
+}
+
+// retryLater put a failed job into retry set with exponential backoff, drop it if it can never be delivered
+func retryLater(job *Job, err error) {
+	retryAfter, blocked := ParseSendError(err)
+	if blocked {
+		log.Printf("Notification %s dropped, user %d unreachable: %s\n", job.Id, job.Uid, err)
+		return
+	}
+	job.Attempts++
+	if job.Attempts > maxRetries {
+		log.Printf("Notification %s dropped after %d attempts: %s\n", job.Id, job.Attempts, err)
+		return
+	}
+	if retryAfter <= 0 {
+		retryAfter = time.Duration(math.Min(float64(retryBaseDelay)*math.Pow(2, float64(job.Attempts-1)), float64(retryMaxDelay)))
+	}
+	raw, _ := json.Marshal(job)
+	if e0 := app.Cache().ZSetAdd(caches.GenNotifyRetryCacheKey(), float64(time.Now().Add(retryAfter).UnixMilli()), string(raw)); e0 != nil {
+		log.Printf("Notification %s schedule retry failed: %s\n", job.Id, e0)
+	}
+}
+
+// deliver check user preferences, render template in user language and send it
+func deliver(bot *telego.Bot, job *Job) error {
+	pref, e0 := app.DB().NotificationPrefFindByUid(job.Uid)
+	if e0 != nil {
+		return e0
+	}
+	if !isEnabled(pref, job.Type) {
+		return nil
+	}
+	user, e1 := caches.UserFindByIdCached(app.Cache(), app.DB(), job.Uid, app.Config().Basic.SessionExpiresSec)
+	if e1 != nil || user == nil {
+		log.Printf("Notification %s dropped, user %d not found\n", job.Id, job.Uid)
+		return nil
+	}
+
+	lang := i18n.Normalize(user.LanguageCode)
+	return SendMessage(bot, &telego.SendMessageParams{
+		ChatID: tu.ID(job.Uid),
+		Text:   render(lang, job),
+		ReplyMarkup: tu.InlineKeyboard(
+			tu.InlineKeyboardRow(tu.InlineKeyboardButton(i18n.T(lang, i18n.MsgOpenWallet)).WithWebApp(&telego.WebAppInfo{URL: app.Config().Bot.WebUrl})),
+		),
+	})
+}
+
+func render(lang string, job *Job) string {
+	args := make([]interface{}, len(job.Args))
+	for i, arg := range job.Args {
+		args[i] = arg
+		if arg == "" {
+			args[i] = i18n.T(lang, i18n.MsgNotifySomeone)
+		}
+	}
+	return i18n.T(lang, templates[job.Type], args...)
+}
+
+func isEnabled(pref *dbs.NotificationPref, notifyType string) bool {
+	switch notifyType {
+	case configs.NotifyTypeMomentLike:
+		return pref.MomentLike
+	case configs.NotifyTypeMomentComment:
+		return pref.MomentComment
+	case configs.NotifyTypeMomentReward:
+		return pref.MomentReward
+	case configs.NotifyTypeCheckinReminder:
+		return pref.CheckinReminder
+	case configs.NotifyTypePriceAboveAlert, configs.NotifyTypePriceBelowAlert:
+		return true // users create alerts themselves
+	case configs.NotifyTypeAchievement:
+		return true // badges are rare and expected
+	default:
+		return false
+	}
+}
@@ -0,0 +1,129 @@
+package prices
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultBinanceBaseUrl = "https://api.binance.com"
+	binanceQuoteSymbol    = "USDT"
+)
+
+type binancePrice struct {
+	Symbol string      `json:"symbol"` // BTCUSDT
+	Price  json.Number `json:"price"`
+}
+
+// BinanceProvider price of SYMBOL/USDT spot pairs, USDT itself is 1
+type BinanceProvider struct {
+	name    string
+	baseUrl string
+	client  *http.Client
+	pairs   map[string]string // coin symbol to pair, default {symbol}USDT
+}
+
+func NewBinanceProvider(name string, baseUrl string, pairs map[string]string, client *http.Client) *BinanceProvider {
+	if baseUrl == "" {
+		baseUrl = defaultBinanceBaseUrl
+	}
+	return &BinanceProvider{name: name, baseUrl: strings.TrimSuffix(baseUrl, "/"), client: client, pairs: normalizeSymbols(pairs)}
+}
+
+func (p *BinanceProvider) Name() string {
+	return p.name
+}
+
+// FetchUSDPrices query all tickers at once, querying listed symbols fails the whole request if one is unknown
+func (p *BinanceProvider) FetchUSDPrices(ctx context.Context, symbols []string) (map[string]float64, error) {
+	req, e0 := http.NewRequestWithContext(ctx, http.MethodGet, p.baseUrl+"/api/v3/ticker/price", nil)
+	if e0 != nil {
+		return nil, e0
+	}
+	body, e1 := doRequest(p.client, req)
+	if e1 != nil {
+		return nil, e1
+	}
+	var tickers []*binancePrice
+	if e2 := json.Unmarshal(body, &tickers); e2 != nil {
+		return nil, e2
+	}
+
+	tickerMap := make(map[string]float64, len(tickers))
+	for _, ticker := range tickers {
+		if price, e3 := ticker.Price.Float64(); e3 == nil && price > 0 {
+			tickerMap[ticker.Symbol] = price
+		}
+	}
+	result := make(map[string]float64)
+	for _, symbol := range symbols {
+		if symbol == binanceQuoteSymbol {
+			result[symbol] = 1
+			continue
+		}
+		pair, ok := p.pairs[symbol]
+		if !ok {
+			pair = symbol + binanceQuoteSymbol
+		}
+		if price, ok := tickerMap[pair]; ok {
+			result[symbol] = price
+		}
+	}
+	return result, nil
+}
+
+// FetchCandles query klines of SYMBOL/USDT, at most MaxCandles are returned
+func (p *BinanceProvider) FetchCandles(ctx context.Context, symbol string, interval string, from time.Time, to time.Time) ([]*Candle, error) {
+	pair, ok := p.pairs[symbol]
+	if !ok {
+		pair = symbol + binanceQuoteSymbol
+	}
+	params := url.Values{}
+	params.Set("symbol", pair)
+	params.Set("interval", interval)
+	params.Set("startTime", strconv.FormatInt(from.UnixMilli(), 10))
+	params.Set("endTime", strconv.FormatInt(to.UnixMilli(), 10))
+	params.Set("limit", strconv.Itoa(MaxCandles))
+	req, e0 := http.NewRequestWithContext(ctx, http.MethodGet, p.baseUrl+"/api/v3/klines?"+params.Encode(), nil)
+	if e0 != nil {
+		return nil, e0
+	}
+	body, e1 := doRequest(p.client, req)
+	if e1 != nil {
+		return nil, e1
+	}
+
+	// [[openTime, "open", "high", "low", "close", "volume", closeTime, ...], ...]
+	var klines [][]interface{}
+	if e2 := json.Unmarshal(body, &klines); e2 != nil {
+		return nil, e2
+	}
+	candles := make([]*Candle, 0, len(klines))
+	for _, kline := range klines {
+		if len(kline) < 6 {
+			return nil, fmt.Errorf("bad kline: %v", kline)
+		}
+		openTime, _ := kline[0].(float64)
+		candles = append(candles, &Candle{
+			OpenTime: int64(openTime) / 1000,
+			Open:     parseKlineValue(kline[1]),
+			High:     parseKlineValue(kline[2]),
+			Low:      parseKlineValue(kline[3]),
+			Close:    parseKlineValue(kline[4]),
+			Volume:   parseKlineValue(kline[5]),
+		})
+	}
+	return candles, nil
+}
+
+func parseKlineValue(value interface{}) float64 {
+	str, _ := value.(string)
+	f, _ := strconv.ParseFloat(str, 64)
+	return f
+}
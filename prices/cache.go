@@ -0,0 +1,149 @@
+package prices
+
+import (
+	"context"
+	"errors"
+	"game-mining-server/caches"
+	"game-mining-server/configs"
+	"github.com/go-redsync/redsync/v4"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	defaultWatchlistRefreshPeriod = 20 * time.Second
+	defaultPriceCacheTtlSec       = 60
+)
+
+// priceCall an in flight provider request shared by concurrent cache misses
+type priceCall struct {
+	done   chan struct{}
+	prices map[string]float64
+	err    error
+}
+
+// flightGroup de-duplicate concurrent fetches per symbol, a request only fetches symbols nobody is fetching
+type flightGroup struct {
+	mu    sync.Mutex
+	calls map[string]*priceCall
+}
+
+func (g *flightGroup) do(symbols []string, fetch func(symbols []string) (map[string]float64, error)) (map[string]float64, error) {
+	own := &priceCall{done: make(chan struct{})}
+	var ownSymbols []string
+	waits := make(map[*priceCall]bool)
+
+	g.mu.Lock()
+	for _, symbol := range symbols {
+		if call, ok := g.calls[symbol]; ok {
+			waits[call] = true
+		} else {
+			g.calls[symbol] = own
+			ownSymbols = append(ownSymbols, symbol)
+		}
+	}
+	g.mu.Unlock()
+
+	if len(ownSymbols) > 0 {
+		own.prices, own.err = fetch(ownSymbols)
+		g.mu.Lock()
+		for _, symbol := range ownSymbols {
+			delete(g.calls, symbol)
+		}
+		g.mu.Unlock()
+		close(own.done)
+		waits[own] = true
+	}
+
+	result := make(map[string]float64)
+	var errs []error
+	for call := range waits {
+		<-call.done
+		if call.err != nil {
+			errs = append(errs, call.err)
+		}
+		for _, symbol := range symbols {
+			if price, ok := call.prices[symbol]; ok {
+				result[symbol] = price
+			}
+		}
+	}
+	if len(result) == 0 && len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return result, nil
+}
+
+// GetCachedUSDPrices get usd prices from cache in one round trip, misses are fetched once however many requests miss
+func (s *Service) GetCachedUSDPrices(ctx context.Context, symbols []string) (map[string]float64, error) {
+	keys := make([]string, len(symbols))
+	for i, symbol := range symbols {
+		keys[i] = caches.GenCoinPriceCacheKey(configs.FiatUSD, symbol)
+	}
+
+	prices := make(map[string]float64, len(symbols))
+	var missing []string
+	cached, e0 := s.cache.MGetString(keys...)
+	for i, symbol := range symbols {
+		if e0 == nil && cached[i] != "" {
+			if price, e1 := strconv.ParseFloat(cached[i], 64); e1 == nil {
+				prices[symbol] = price
+				continue
+			}
+		}
+		missing = append(missing, symbol)
+	}
+	if len(missing) == 0 {
+		return prices, nil
+	}
+
+	fetched, e2 := s.flights.do(missing, func(symbols []string) (map[string]float64, error) {
+		// a shared fetch must not fail because the request that started it went away
+		return s.fetchAndCache(context.WithoutCancel(ctx), symbols)
+	})
+	if e2 != nil {
+		return nil, e2
+	}
+	for symbol, price := range fetched {
+		prices[symbol] = price
+	}
+	return prices, nil
+}
+
+// fetchAndCache fetch prices from providers and cache them
+func (s *Service) fetchAndCache(ctx context.Context, symbols []string) (map[string]float64, error) {
+	prices, e0 := s.GetUSDPrices(ctx, symbols)
+	if e0 != nil {
+		return nil, e0
+	}
+	items := make(map[string]string, len(prices))
+	for symbol, price := range prices {
+		items[caches.GenCoinPriceCacheKey(configs.FiatUSD, symbol)] = strconv.FormatFloat(price, 'E', -1, 64)
+	}
+	if len(items) > 0 {
+		if e1 := s.cache.MSetString(items, s.cacheTtlSec); e1 != nil {
+			log.Printf("Cache coin prices failed: %s", e1)
+		}
+		s.notifyRefreshed(prices)
+	}
+	return prices, nil
+}
+
+// refreshWatchlist keep watchlist prices in cache before they expire, the lock is not released after refresh,
+// so only one replica refreshes in each interval
+func (s *Service) refreshWatchlist() {
+	ticker := time.NewTicker(s.watchlistRefreshPeriod)
+	defer ticker.Stop()
+	for range ticker.C {
+		mutex := s.cache.RedSyncLock.NewMutex(caches.GenLockCacheKey("priceWatchlist"),
+			redsync.WithExpiry(max(s.watchlistRefreshPeriod-time.Second, time.Second)), redsync.WithTries(1))
+		if e0 := mutex.Lock(); e0 != nil {
+			continue
+		}
+		if _, e1 := s.fetchAndCache(context.Background(), s.watchlist); e1 != nil {
+			log.Printf("Refresh price watchlist failed: %s", e1)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package prices
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const defaultCoinGeckoBaseUrl = "https://api.coingecko.com"
+
+// CoinGeckoProvider price from coingecko style /api/v3/simple/price, only mapped symbols are queried
+type CoinGeckoProvider struct {
+	name    string
+	baseUrl string
+	apiKey  string
+	client  *http.Client
+	ids     map[string]string // coin symbol to coin id, e.g: BNB to binancecoin
+}
+
+func NewCoinGeckoProvider(name string, baseUrl string, apiKey string, ids map[string]string, client *http.Client) *CoinGeckoProvider {
+	if baseUrl == "" {
+		baseUrl = defaultCoinGeckoBaseUrl
+	}
+	return &CoinGeckoProvider{name: name, baseUrl: strings.TrimSuffix(baseUrl, "/"), apiKey: apiKey, client: client, ids: normalizeSymbols(ids)}
+}
+
+func (p *CoinGeckoProvider) Name() string {
+	return p.name
+}
+
+func (p *CoinGeckoProvider) FetchUSDPrices(ctx context.Context, symbols []string) (map[string]float64, error) {
+	result := make(map[string]float64)
+	var ids []string
+	for _, symbol := range symbols {
+		if id, ok := p.ids[symbol]; ok {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		return result, nil
+	}
+
+	params := url.Values{}
+	params.Set("ids", strings.Join(ids, ","))
+	params.Set("vs_currencies", "usd")
+	req, e0 := http.NewRequestWithContext(ctx, http.MethodGet, p.baseUrl+"/api/v3/simple/price?"+params.Encode(), nil)
+	if e0 != nil {
+		return nil, e0
+	}
+	if p.apiKey != "" {
+		req.Header.Set("x-cg-pro-api-key", p.apiKey)
+	}
+	body, e1 := doRequest(p.client, req)
+	if e1 != nil {
+		return nil, e1
+	}
+	var quotes map[string]map[string]float64 // {"binancecoin":{"usd":580.1}}
+	if e2 := json.Unmarshal(body, &quotes); e2 != nil {
+		return nil, e2
+	}
+	for _, symbol := range symbols {
+		if id, ok := p.ids[symbol]; ok {
+			if price := quotes[id]["usd"]; price > 0 {
+				result[symbol] = price
+			}
+		}
+	}
+	return result, nil
+}
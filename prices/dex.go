@@ -0,0 +1,110 @@
+package prices
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"game-mining-server/configs"
+	"math/big"
+	"net/http"
+	"strings"
+)
+
+// getReservesSelector function selector of uniswap v2 pair getReserves()
+const getReservesSelector = "0x0902f1ac"
+
+// DexPoolProvider price from reserves of uniswap v2 style pairs of a coin and a usd stablecoin
+type DexPoolProvider struct {
+	name   string
+	client *http.Client
+	pools  map[string]*configs.PriceDexPoolConfig // coin symbol to pool
+}
+
+func NewDexPoolProvider(name string, pools []*configs.PriceDexPoolConfig, client *http.Client) *DexPoolProvider {
+	p := &DexPoolProvider{name: name, client: client, pools: make(map[string]*configs.PriceDexPoolConfig)}
+	for _, pool := range pools {
+		p.pools[strings.ToUpper(pool.Symbol)] = pool
+	}
+	return p
+}
+
+func (p *DexPoolProvider) Name() string {
+	return p.name
+}
+
+// FetchUSDPrices read reserves of each configured pool, a pool failing is an error since pools are explicitly configured
+func (p *DexPoolProvider) FetchUSDPrices(ctx context.Context, symbols []string) (map[string]float64, error) {
+	result := make(map[string]float64)
+	var errs []error
+	for _, symbol := range symbols {
+		pool, ok := p.pools[symbol]
+		if !ok {
+			continue
+		}
+		price, e0 := p.poolPrice(ctx, pool)
+		if e0 != nil {
+			errs = append(errs, fmt.Errorf("%s pool: %w", symbol, e0))
+			continue
+		}
+		result[symbol] = price
+	}
+	if len(result) == 0 && len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return result, nil
+}
+
+func (p *DexPoolProvider) poolPrice(ctx context.Context, pool *configs.PriceDexPoolConfig) (float64, error) {
+	payload, _ := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  "eth_call",
+		"params":  []interface{}{map[string]string{"to": pool.Pair, "data": getReservesSelector}, "latest"},
+	})
+	req, e0 := http.NewRequestWithContext(ctx, http.MethodPost, pool.RpcUrl, bytes.NewReader(payload))
+	if e0 != nil {
+		return 0, e0
+	}
+	req.Header.Set("Content-Type", "application/json")
+	body, e1 := doRequest(p.client, req)
+	if e1 != nil {
+		return 0, e1
+	}
+	var res struct {
+		Result string `json:"result"`
+		Error  *struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if e2 := json.Unmarshal(body, &res); e2 != nil {
+		return 0, e2
+	}
+	if res.Error != nil {
+		return 0, errors.New(res.Error.Message)
+	}
+
+	// result is reserve0, reserve1 and blockTimestampLast, each a 32 bytes word
+	data := strings.TrimPrefix(res.Result, "0x")
+	if len(data) < 128 {
+		return 0, fmt.Errorf("bad getReserves result: %s", res.Result)
+	}
+	reserve0, ok0 := new(big.Int).SetString(data[:64], 16)
+	reserve1, ok1 := new(big.Int).SetString(data[64:128], 16)
+	if !ok0 || !ok1 || reserve0.Sign() == 0 || reserve1.Sign() == 0 {
+		return 0, fmt.Errorf("bad reserves: %s", res.Result)
+	}
+	baseReserve, quoteReserve := reserve1, reserve0
+	if pool.BaseIsToken0 {
+		baseReserve, quoteReserve = reserve0, reserve1
+	}
+	base := new(big.Float).Quo(new(big.Float).SetInt(baseReserve), pow10(pool.BaseDecimals))
+	quote := new(big.Float).Quo(new(big.Float).SetInt(quoteReserve), pow10(pool.QuoteDecimals))
+	price, _ := new(big.Float).Quo(quote, base).Float64()
+	return price, nil
+}
+
+func pow10(decimals int) *big.Float {
+	return new(big.Float).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil))
+}
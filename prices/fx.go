@@ -0,0 +1,131 @@
+package prices
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"game-mining-server/configs"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultFxBaseUrl       = "https://open.er-api.com"
+	defaultFxRefreshPeriod = time.Hour
+)
+
+var defaultFiats = []string{"USD", "EUR", "GBP", "JPY", "CNY", "HKD", "SGD", "KRW", "INR", "IDR", "VND", "RUB", "UAH", "TRY", "BRL", "AUD", "CAD"}
+
+// FiatRate usd to fiat exchange rate
+type FiatRate struct {
+	FiatSymbol string  `json:"fiatSymbol"`
+	Rate       float64 `json:"rate"`      // 1 USD in fiat
+	UpdatedAt  int64   `json:"updatedAt"` // unix seconds
+}
+
+// FxRates usd exchange rates of supported fiats, kept in memory and refreshed periodically
+type FxRates struct {
+	baseUrl       string
+	client        *http.Client
+	fiats         []string
+	refreshPeriod time.Duration
+
+	mu        sync.RWMutex
+	rates     map[string]float64
+	updatedAt int64
+}
+
+func newFxRates(cfg *configs.PriceConfig, client *http.Client) *FxRates {
+	f := &FxRates{
+		baseUrl:       defaultFxBaseUrl,
+		client:        client,
+		fiats:         defaultFiats,
+		refreshPeriod: defaultFxRefreshPeriod,
+		rates:         map[string]float64{configs.FiatUSD: 1},
+	}
+	if cfg != nil {
+		if cfg.FxBaseUrl != "" {
+			f.baseUrl = strings.TrimSuffix(cfg.FxBaseUrl, "/")
+		}
+		if len(cfg.Fiats) > 0 {
+			f.fiats = nil
+			for _, fiat := range cfg.Fiats {
+				f.fiats = append(f.fiats, strings.ToUpper(fiat))
+			}
+		}
+		if cfg.FxRefreshSec > 0 {
+			f.refreshPeriod = time.Duration(cfg.FxRefreshSec) * time.Second
+		}
+	}
+	return f
+}
+
+// Refresh fetch latest rates, old rates are kept if the request fails
+func (f *FxRates) Refresh(ctx context.Context) error {
+	req, e0 := http.NewRequestWithContext(ctx, http.MethodGet, f.baseUrl+"/v6/latest/USD", nil)
+	if e0 != nil {
+		return e0
+	}
+	body, e1 := doRequest(f.client, req)
+	if e1 != nil {
+		return e1
+	}
+	var res struct {
+		Result string             `json:"result"`
+		Rates  map[string]float64 `json:"rates"`
+	}
+	if e2 := json.Unmarshal(body, &res); e2 != nil {
+		return e2
+	}
+	if res.Result != "" && res.Result != "success" {
+		return fmt.Errorf("fx rates result: %s", res.Result)
+	}
+
+	rates := map[string]float64{configs.FiatUSD: 1}
+	for _, fiat := range f.fiats {
+		if rate := res.Rates[fiat]; rate > 0 {
+			rates[fiat] = rate
+		}
+	}
+	f.mu.Lock()
+	f.rates = rates
+	f.updatedAt = time.Now().Unix()
+	f.mu.Unlock()
+	return nil
+}
+
+// IsSupported whether fiat is in supported list
+func (f *FxRates) IsSupported(fiat string) bool {
+	for _, supported := range f.fiats {
+		if supported == fiat {
+			return true
+		}
+	}
+	return fiat == configs.FiatUSD
+}
+
+// Rate return 1 USD in fiat, false if fiat is not supported or its rate is not loaded yet
+func (f *FxRates) Rate(fiat string) (float64, bool) {
+	if !f.IsSupported(fiat) {
+		return 0, false
+	}
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	rate, ok := f.rates[fiat]
+	return rate, ok
+}
+
+// Fiats return loaded rates of supported fiats in configured order
+func (f *FxRates) Fiats() []*FiatRate {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	fiatRates := make([]*FiatRate, 0, len(f.fiats))
+	for _, fiat := range f.fiats {
+		if rate, ok := f.rates[fiat]; ok {
+			fiatRates = append(fiatRates, &FiatRate{FiatSymbol: fiat, Rate: rate, UpdatedAt: f.updatedAt})
+		}
+	}
+	return fiatRates
+}
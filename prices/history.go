@@ -0,0 +1,93 @@
+package prices
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"game-mining-server/caches"
+	"game-mining-server/configs"
+	"log"
+	"time"
+)
+
+const (
+	MaxCandles            = 1000
+	openCandlesCacheSec   = 60        // range includes the current candle, which still changes
+	closedCandlesCacheSec = 24 * 3600 // all candles are closed and never change
+	missedCandlesCacheSec = 60        // candles of unknown symbols or failed fetches are not asked again for a while
+	missedCandlesMarker   = "-"
+)
+
+var (
+	ErrInvalidInterval  = errors.New("interval must be 1h or 1d")
+	ErrInvalidRange     = fmt.Errorf("range must end after it starts and hold at most %d candles", MaxCandles)
+	ErrNoCandleProvider = errors.New("no price provider supports candles")
+	ErrCandlesMissed    = errors.New("candles recently failed to fetch")
+)
+
+var intervalDurations = map[string]time.Duration{
+	configs.PriceInterval1h: time.Hour,
+	configs.PriceInterval1d: 24 * time.Hour,
+}
+
+// GetCandles get usd candles of symbol whose open time is within [from, to], range is aligned to interval and cached.
+// A failed fetch is cached briefly too, ErrCandlesMissed is returned until it expires
+func (s *Service) GetCandles(ctx context.Context, symbol string, interval string, from time.Time, to time.Time) ([]*Candle, error) {
+	duration, ok := intervalDurations[interval]
+	if !ok {
+		return nil, ErrInvalidInterval
+	}
+	from, to = from.UTC().Truncate(duration), to.UTC().Truncate(duration)
+	if to.Before(from) || int(to.Sub(from)/duration)+1 > MaxCandles {
+		return nil, ErrInvalidRange
+	}
+
+	key := caches.GenCoinPriceHistoryCacheKey(symbol, interval, from.Unix(), to.Unix())
+	if cached, e0 := s.cache.GetString(key); e0 == nil && cached == missedCandlesMarker {
+		return nil, ErrCandlesMissed
+	} else if e0 == nil && cached != "" {
+		var candles []*Candle
+		if e1 := json.Unmarshal([]byte(cached), &candles); e1 == nil {
+			return candles, nil
+		}
+	}
+
+	provider := s.candleProvider()
+	if provider == nil {
+		return nil, ErrNoCandleProvider
+	}
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	candles, e2 := provider.FetchCandles(ctx, symbol, interval, from, to)
+	if e2 != nil {
+		// a cancelled request says nothing about the symbol
+		if ctx.Err() == nil {
+			if e3 := s.cache.SetString(key, missedCandlesMarker, missedCandlesCacheSec); e3 != nil {
+				log.Printf("Cache missed candles %s failed: %s", key, e3)
+			}
+		}
+		return nil, e2
+	}
+
+	expiresSec := openCandlesCacheSec
+	if !to.Add(duration).After(time.Now()) {
+		expiresSec = closedCandlesCacheSec
+	}
+	if data, e3 := json.Marshal(candles); e3 == nil {
+		if e4 := s.cache.SetString(key, string(data), expiresSec); e4 != nil {
+			log.Printf("Cache candles %s failed: %s", key, e4)
+		}
+	}
+	return candles, nil
+}
+
+// candleProvider first healthy provider which supports candles
+func (s *Service) candleProvider() CandleProvider {
+	for _, provider := range s.availableProviders() {
+		if candleProvider, ok := provider.Provider.(CandleProvider); ok {
+			return candleProvider
+		}
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package prices
+
+import (
+	"context"
+	"game-mining-server/configs"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// pricesAtConcurrency max symbols whose past prices are fetched at once
+const pricesAtConcurrency = 4
+
+// Holding an amount of coin held by user
+type Holding struct {
+	CoinSymbol string
+	Amount     float64
+}
+
+// AssetValue fiat value of a holding, 24h fields are nil if the price 24h ago is unknown
+type AssetValue struct {
+	CoinSymbol  string   `json:"coinSymbol"`
+	Amount      float64  `json:"amount"`
+	Price       float64  `json:"price"`       // fiat price
+	Value       float64  `json:"value"`       // amount * price
+	Price24hAgo *float64 `json:"price24hAgo"` // fiat price 24h ago
+	Change24h   *float64 `json:"change24h"`   // price change in percent: 1.5 means +1.5%
+}
+
+// Portfolio fiat value of all holdings, coins without price are listed in Unpriced and not counted
+type Portfolio struct {
+	FiatSymbol  string        `json:"fiatSymbol"`
+	TotalValue  float64       `json:"totalValue"`
+	Value24hAgo float64       `json:"value24hAgo"` // value of the same amounts 24h ago, only coins with 24h price are counted
+	Change24h   *float64      `json:"change24h"`   // value change in percent of coins with 24h price, nil if none has
+	Assets      []*AssetValue `json:"assets"`      // sorted by value, largest first
+	Unpriced    []string      `json:"unpriced"`
+}
+
+// Valuate value holdings in fiat with cached usd prices and usd exchange rate of fiat, amounts of the same coin are
+// added up. Prices 24h ago are converted with the current rate, so changes only reflect coin prices.
+func (s *Service) Valuate(ctx context.Context, holdings []*Holding, fiatSymbol string, rate float64) (*Portfolio, error) {
+	amounts := make(map[string]float64)
+	var symbols []string
+	for _, holding := range holdings {
+		symbol := strings.ToUpper(holding.CoinSymbol)
+		if _, ok := amounts[symbol]; !ok {
+			symbols = append(symbols, symbol)
+		}
+		amounts[symbol] += holding.Amount
+	}
+
+	usdPrices, e0 := s.GetCachedUSDPrices(ctx, symbols)
+	if e0 != nil {
+		return nil, e0
+	}
+	// only priced coins are valued, so only their prices 24h ago are fetched
+	priced := make([]string, 0, len(usdPrices))
+	for _, symbol := range symbols {
+		if _, ok := usdPrices[symbol]; ok {
+			priced = append(priced, symbol)
+		}
+	}
+	usdPrices24hAgo := s.GetUSDPricesAt(ctx, priced, time.Now().Add(-24*time.Hour))
+
+	portfolio := &Portfolio{FiatSymbol: fiatSymbol, Assets: []*AssetValue{}, Unpriced: []string{}}
+	valueWith24h := 0.0
+	for _, symbol := range symbols {
+		usdPrice, ok := usdPrices[symbol]
+		if !ok {
+			portfolio.Unpriced = append(portfolio.Unpriced, symbol)
+			continue
+		}
+		asset := &AssetValue{CoinSymbol: symbol, Amount: amounts[symbol], Price: usdPrice * rate}
+		asset.Value = asset.Amount * asset.Price
+		portfolio.TotalValue += asset.Value
+		if usdPrice24hAgo, ok := usdPrices24hAgo[symbol]; ok && usdPrice24hAgo > 0 {
+			price24hAgo := usdPrice24hAgo * rate
+			change := (usdPrice/usdPrice24hAgo - 1) * 100
+			asset.Price24hAgo, asset.Change24h = &price24hAgo, &change
+			portfolio.Value24hAgo += asset.Amount * price24hAgo
+			valueWith24h += asset.Value
+		}
+		portfolio.Assets = append(portfolio.Assets, asset)
+	}
+	if portfolio.Value24hAgo > 0 {
+		change := (valueWith24h/portfolio.Value24hAgo - 1) * 100
+		portfolio.Change24h = &change
+	}
+	sort.SliceStable(portfolio.Assets, func(i, j int) bool {
+		return portfolio.Assets[i].Value > portfolio.Assets[j].Value
+	})
+	return portfolio, nil
+}
+
+// GetUSDPricesAt get usd prices of symbols at a past time from open prices of 1h candles, which are cached once closed.
+// At most pricesAtConcurrency symbols are fetched at once, symbols whose candles can not be fetched are left out.
+func (s *Service) GetUSDPricesAt(ctx context.Context, symbols []string, at time.Time) map[string]float64 {
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, pricesAtConcurrency)
+	prices := make(map[string]float64, len(symbols))
+	for _, symbol := range symbols {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(symbol string) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			candles, e0 := s.GetCandles(ctx, symbol, configs.PriceInterval1h, at, at)
+			if e0 != nil || len(candles) == 0 {
+				return
+			}
+			mu.Lock()
+			prices[symbol] = candles[0].Open
+			mu.Unlock()
+		}(symbol)
+	}
+	wg.Wait()
+	return prices
+}
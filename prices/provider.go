@@ -0,0 +1,72 @@
+package prices
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const maxProviderResponseBytes = int64(8 << 20)
+
+// Provider A coin usd price source
+type Provider interface {
+	// Name provider name used in health status
+	Name() string
+	// FetchUSDPrices fetch usd prices of symbols, symbols the provider does not know are left out of the result
+	FetchUSDPrices(ctx context.Context, symbols []string) (map[string]float64, error)
+}
+
+// normalizeSymbols upper case keys of symbol mapping config, config loader lower cases map keys
+func normalizeSymbols(symbols map[string]string) map[string]string {
+	normalized := make(map[string]string, len(symbols))
+	for symbol, id := range symbols {
+		normalized[strings.ToUpper(symbol)] = id
+	}
+	return normalized
+}
+
+// doRequest send request and read body, non 2xx statuses are errors
+func doRequest(client *http.Client, req *http.Request) ([]byte, error) {
+	res, e0 := client.Do(req)
+	if e0 != nil {
+		return nil, e0
+	}
+	defer func() {
+		_ = res.Body.Close()
+	}()
+	body, e1 := io.ReadAll(io.LimitReader(res.Body, maxProviderResponseBytes))
+	if e1 != nil {
+		return nil, e1
+	}
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return nil, fmt.Errorf("%s responded %d: %s", req.URL.Host, res.StatusCode, truncate(string(body), 200))
+	}
+	return body, nil
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
+
+// Candle OHLC of an interval, prices in usd
+type Candle struct {
+	OpenTime int64   `json:"openTime"` // unix seconds
+	Open     float64 `json:"open"`
+	High     float64 `json:"high"`
+	Low      float64 `json:"low"`
+	Close    float64 `json:"close"`
+	Volume   float64 `json:"volume"` // base coin volume
+}
+
+// CandleProvider A coin usd candle source
+type CandleProvider interface {
+	Name() string
+	// FetchCandles fetch candles of symbol whose open time is within [from, to], interval is 1h or 1d
+	FetchCandles(ctx context.Context, symbol string, interval string, from time.Time, to time.Time) ([]*Candle, error)
+}
@@ -0,0 +1,142 @@
+package prices
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"game-mining-server/configs"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// reservesResult getReserves() result of reserve0, reserve1 and a zero timestamp
+func reservesResult(reserve0, reserve1 *big.Int) string {
+	return fmt.Sprintf("0x%064x%064x%064x", reserve0, reserve1, 0)
+}
+
+func units(amount int64, decimals int) *big.Int {
+	return new(big.Int).Mul(big.NewInt(amount), new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil))
+}
+
+func TestBinanceProvider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v3/ticker/price" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		_, _ = io.WriteString(w, `[{"symbol":"BTCUSDT","price":"65000.50"},{"symbol":"BNBUSDT","price":"580"},`+
+			`{"symbol":"WBNBUSDT","price":"581"},{"symbol":"ZEROUSDT","price":"0"}]`)
+	}))
+	defer server.Close()
+
+	// config loader lower cases map keys, mapping is matched by upper case symbol
+	p := NewBinanceProvider("binance", server.URL+"/", map[string]string{"wbnb": "BNBUSDT"}, server.Client())
+	prices, e := p.FetchUSDPrices(context.Background(), []string{"BTC", "WBNB", "USDT", "ZERO", "UNKNOWN"})
+	if e != nil {
+		t.Fatal(e)
+	}
+	want := map[string]float64{"BTC": 65000.5, "WBNB": 580, "USDT": 1}
+	assertPrices(t, prices, want)
+}
+
+func TestBinanceProviderError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"code":-1003,"msg":"too many requests"}`, http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	p := NewBinanceProvider("binance", server.URL, nil, server.Client())
+	if _, e := p.FetchUSDPrices(context.Background(), []string{"BTC"}); e == nil || !strings.Contains(e.Error(), "429") {
+		t.Fatalf("want status error, got %v", e)
+	}
+}
+
+func TestCoinGeckoProvider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v3/simple/price" {
+			t.Errorf("unexpected path %s", r.URL.Path)
+		}
+		if got := r.URL.Query().Get("ids"); got != "bitcoin,binancecoin" {
+			t.Errorf("only mapped ids should be queried, got %s", got)
+		}
+		if got := r.Header.Get("x-cg-pro-api-key"); got != "key" {
+			t.Errorf("api key header: got %q", got)
+		}
+		_, _ = io.WriteString(w, `{"bitcoin":{"usd":65010},"binancecoin":{"usd":0}}`)
+	}))
+	defer server.Close()
+
+	ids := map[string]string{"btc": "bitcoin", "bnb": "binancecoin"}
+	p := NewCoinGeckoProvider("coingecko", server.URL, "key", ids, server.Client())
+	prices, e := p.FetchUSDPrices(context.Background(), []string{"BTC", "BNB", "ETH"})
+	if e != nil {
+		t.Fatal(e)
+	}
+	assertPrices(t, prices, map[string]float64{"BTC": 65010})
+
+	// no mapped symbol, nothing is requested
+	none, e := p.FetchUSDPrices(context.Background(), []string{"ETH"})
+	if e != nil || len(none) != 0 {
+		t.Fatalf("want empty result, got %v, %v", none, e)
+	}
+}
+
+func TestDexPoolProvider(t *testing.T) {
+	pairs := map[string]string{
+		"0xcake": reservesResult(units(2000, 18), units(1000, 18)),  // cake is token0: 2000 cake, 1000 usdt
+		"0xbnb":  reservesResult(units(600000, 6), units(1000, 18)), // bnb is token1: 600000 usdc, 1000 bnb
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Method string `json:"method"`
+			Params []json.RawMessage
+		}
+		if e := json.NewDecoder(r.Body).Decode(&req); e != nil || req.Method != "eth_call" || len(req.Params) == 0 {
+			t.Errorf("bad rpc request: %v", e)
+			return
+		}
+		var call map[string]string
+		_ = json.Unmarshal(req.Params[0], &call)
+		if call["data"] != getReservesSelector {
+			t.Errorf("unexpected call data %s", call["data"])
+		}
+		if result, ok := pairs[call["to"]]; ok {
+			_, _ = fmt.Fprintf(w, `{"jsonrpc":"2.0","id":1,"result":"%s"}`, result)
+		} else {
+			_, _ = io.WriteString(w, `{"jsonrpc":"2.0","id":1,"error":{"message":"execution reverted"}}`)
+		}
+	}))
+	defer server.Close()
+
+	pools := []*configs.PriceDexPoolConfig{
+		{Symbol: "cake", RpcUrl: server.URL, Pair: "0xcake", BaseIsToken0: true, BaseDecimals: 18, QuoteDecimals: 18},
+		{Symbol: "BNB", RpcUrl: server.URL, Pair: "0xbnb", BaseDecimals: 18, QuoteDecimals: 6},
+		{Symbol: "BAD", RpcUrl: server.URL, Pair: "0xbad", BaseDecimals: 18, QuoteDecimals: 18},
+	}
+	p := NewDexPoolProvider("dex", pools, server.Client())
+	prices, e := p.FetchUSDPrices(context.Background(), []string{"CAKE", "BNB", "BAD", "BTC"})
+	if e != nil {
+		t.Fatal(e)
+	}
+	assertPrices(t, prices, map[string]float64{"CAKE": 0.5, "BNB": 600})
+
+	// every requested pool failing is an error
+	if _, e := p.FetchUSDPrices(context.Background(), []string{"BAD"}); e == nil || !strings.Contains(e.Error(), "execution reverted") {
+		t.Fatalf("want pool error, got %v", e)
+	}
+}
+
+func assertPrices(t *testing.T, got map[string]float64, want map[string]float64) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+	for symbol, price := range want {
+		if got[symbol] != price {
+			t.Errorf("%s: want %v, got %v", symbol, price, got[symbol])
+		}
+	}
+}
@@ -0,0 +1,315 @@
+package prices
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"game-mining-server/caches"
+	"game-mining-server/configs"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultTimeout          = 10 * time.Second
+	defaultFailureThreshold = 3
+	defaultCooldown         = time.Minute
+)
+
+var ErrNoProviderAvailable = errors.New("no price provider available")
+
+// ProviderHealth health status of a provider
+type ProviderHealth struct {
+	Name                string `json:"name"`
+	Healthy             bool   `json:"healthy"`
+	ConsecutiveFailures int    `json:"consecutiveFailures"`
+	LastError           string `json:"lastError"`
+	LastSuccessAt       int64  `json:"lastSuccessAt"`  // unix seconds
+	UnhealthyUntil      int64  `json:"unhealthyUntil"` // unix seconds, 0 if healthy
+}
+
+// trackedProvider a provider with its health, it is skipped for cooldown after failureThreshold consecutive failures
+type trackedProvider struct {
+	Provider
+	mu     sync.Mutex
+	health ProviderHealth
+}
+
+type Service struct {
+	Fx                     *FxRates // usd to fiat exchange rates
+	Hub                    *Hub     // price stream fan out
+	cache                  *caches.Service
+	flights                *flightGroup
+	providers              []*trackedProvider
+	strategy               string
+	timeout                time.Duration
+	failureThreshold       int
+	cooldown               time.Duration
+	watchlist              []string
+	watchlistRefreshPeriod time.Duration
+	cacheTtlSec            int
+	listenersMu            sync.RWMutex
+	listeners              []func(prices map[string]float64)
+}
+
+func CreatePriceService(cfg *configs.PriceConfig, cache *caches.Service) (*Service, error) {
+	s := &Service{
+		cache:                  cache,
+		flights:                &flightGroup{calls: make(map[string]*priceCall)},
+		strategy:               configs.PriceStrategyMedian,
+		timeout:                defaultTimeout,
+		failureThreshold:       defaultFailureThreshold,
+		cooldown:               defaultCooldown,
+		watchlistRefreshPeriod: defaultWatchlistRefreshPeriod,
+		cacheTtlSec:            defaultPriceCacheTtlSec,
+	}
+	streamRefreshPeriod := defaultStreamRefreshPeriod
+	providerCfgs := []*configs.PriceProviderConfig{{Name: configs.PriceProviderBinance, Type: configs.PriceProviderBinance}}
+	if cfg != nil {
+		if cfg.Strategy != "" {
+			s.strategy = cfg.Strategy
+		}
+		if cfg.TimeoutSec > 0 {
+			s.timeout = time.Duration(cfg.TimeoutSec) * time.Second
+		}
+		if cfg.FailureThreshold > 0 {
+			s.failureThreshold = cfg.FailureThreshold
+		}
+		if cfg.CooldownSec > 0 {
+			s.cooldown = time.Duration(cfg.CooldownSec) * time.Second
+		}
+		if len(cfg.Providers) > 0 {
+			providerCfgs = cfg.Providers
+		}
+		for _, symbol := range cfg.Watchlist {
+			s.watchlist = append(s.watchlist, strings.ToUpper(symbol))
+		}
+		if cfg.WatchlistRefreshSec > 0 {
+			s.watchlistRefreshPeriod = time.Duration(cfg.WatchlistRefreshSec) * time.Second
+		}
+		if cfg.CacheTtlSec > 0 {
+			s.cacheTtlSec = cfg.CacheTtlSec
+		}
+		if cfg.StreamRefreshSec > 0 {
+			streamRefreshPeriod = time.Duration(cfg.StreamRefreshSec) * time.Second
+		}
+	}
+	s.Hub = newHub(s, streamRefreshPeriod)
+	if s.strategy != configs.PriceStrategyMedian && s.strategy != configs.PriceStrategyFallback {
+		return nil, fmt.Errorf("unknown price strategy: %s", s.strategy)
+	}
+
+	client := &http.Client{Timeout: s.timeout}
+	s.Fx = newFxRates(cfg, client)
+	for _, providerCfg := range providerCfgs {
+		name := providerCfg.Name
+		if name == "" {
+			name = providerCfg.Type
+		}
+		var provider Provider
+		switch providerCfg.Type {
+		case configs.PriceProviderBinance:
+			provider = NewBinanceProvider(name, providerCfg.BaseUrl, providerCfg.Symbols, client)
+		case configs.PriceProviderCoinGecko:
+			provider = NewCoinGeckoProvider(name, providerCfg.BaseUrl, providerCfg.ApiKey, providerCfg.Symbols, client)
+		case configs.PriceProviderDex:
+			provider = NewDexPoolProvider(name, providerCfg.Pools, client)
+		default:
+			return nil, fmt.Errorf("unknown price provider type: %s", providerCfg.Type)
+		}
+		s.AddProvider(provider)
+	}
+	return s, nil
+}
+
+// Run start background refreshers
+func (s *Service) Run() {
+	go s.refreshFxRates()
+	go s.Hub.run()
+	if len(s.watchlist) > 0 {
+		go s.refreshWatchlist()
+	}
+}
+
+// refreshFxRates load fx rates at start, and refresh them every period
+func (s *Service) refreshFxRates() {
+	ticker := time.NewTicker(s.Fx.refreshPeriod)
+	defer ticker.Stop()
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+		if err := s.Fx.Refresh(ctx); err != nil {
+			log.Printf("Refresh fx rates failed: %s", err)
+		}
+		cancel()
+		<-ticker.C
+	}
+}
+
+// OnRefresh add a listener called with fetched usd prices each time prices are refreshed from providers,
+// it is called in its own goroutine and must not modify prices
+func (s *Service) OnRefresh(listener func(prices map[string]float64)) {
+	s.listenersMu.Lock()
+	defer s.listenersMu.Unlock()
+	s.listeners = append(s.listeners, listener)
+}
+
+func (s *Service) notifyRefreshed(prices map[string]float64) {
+	s.listenersMu.RLock()
+	defer s.listenersMu.RUnlock()
+	for _, listener := range s.listeners {
+		go listener(prices)
+	}
+}
+
+// AddProvider append a price source, providers are asked in the order they are added
+func (s *Service) AddProvider(provider Provider) {
+	s.providers = append(s.providers, &trackedProvider{Provider: provider, health: ProviderHealth{Name: provider.Name(), Healthy: true}})
+}
+
+// GetUSDPrices get usd prices of symbols from healthy providers, symbols no provider knows are left out.
+// Error is returned only if every provider failed.
+func (s *Service) GetUSDPrices(ctx context.Context, symbols []string) (map[string]float64, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	providers := s.availableProviders()
+	if len(providers) == 0 {
+		return nil, ErrNoProviderAvailable
+	}
+	if s.strategy == configs.PriceStrategyFallback {
+		return s.fallbackPrices(ctx, providers, symbols)
+	}
+	return s.medianPrices(ctx, providers, symbols)
+}
+
+// medianPrices ask all providers at once, each symbol takes the median of prices it got
+func (s *Service) medianPrices(ctx context.Context, providers []*trackedProvider, symbols []string) (map[string]float64, error) {
+	results := make([]map[string]float64, len(providers))
+	errs := make([]error, len(providers))
+	var wg sync.WaitGroup
+	for i, provider := range providers {
+		wg.Add(1)
+		go func(i int, provider *trackedProvider) {
+			defer wg.Done()
+			results[i], errs[i] = provider.fetch(ctx, symbols, s.failureThreshold, s.cooldown)
+		}(i, provider)
+	}
+	wg.Wait()
+
+	quotes := make(map[string][]float64)
+	succeeded := 0
+	for i, result := range results {
+		if errs[i] != nil {
+			continue
+		}
+		succeeded++
+		for symbol, price := range result {
+			quotes[symbol] = append(quotes[symbol], price)
+		}
+	}
+	if succeeded == 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	prices := make(map[string]float64, len(quotes))
+	for symbol, values := range quotes {
+		prices[symbol] = median(values)
+	}
+	return prices, nil
+}
+
+// fallbackPrices ask providers in order, symbols missing from a provider are asked from the next one
+func (s *Service) fallbackPrices(ctx context.Context, providers []*trackedProvider, symbols []string) (map[string]float64, error) {
+	prices := make(map[string]float64)
+	missing := symbols
+	var errs []error
+	succeeded := 0
+	for _, provider := range providers {
+		if len(missing) == 0 {
+			break
+		}
+		result, e0 := provider.fetch(ctx, missing, s.failureThreshold, s.cooldown)
+		if e0 != nil {
+			errs = append(errs, e0)
+			continue
+		}
+		succeeded++
+		var next []string
+		for _, symbol := range missing {
+			if price, ok := result[symbol]; ok {
+				prices[symbol] = price
+			} else {
+				next = append(next, symbol)
+			}
+		}
+		missing = next
+	}
+	if succeeded == 0 {
+		return nil, errors.Join(errs...)
+	}
+	return prices, nil
+}
+
+// Health return health status of all providers
+func (s *Service) Health() []ProviderHealth {
+	healths := make([]ProviderHealth, 0, len(s.providers))
+	for _, provider := range s.providers {
+		provider.mu.Lock()
+		healths = append(healths, provider.health)
+		provider.mu.Unlock()
+	}
+	return healths
+}
+
+// availableProviders providers not in cooldown, or all of them when every provider is in cooldown
+func (s *Service) availableProviders() []*trackedProvider {
+	now := time.Now().Unix()
+	var available []*trackedProvider
+	for _, provider := range s.providers {
+		provider.mu.Lock()
+		if provider.health.UnhealthyUntil <= now {
+			available = append(available, provider)
+		}
+		provider.mu.Unlock()
+	}
+	if len(available) == 0 {
+		return s.providers
+	}
+	return available
+}
+
+func (p *trackedProvider) fetch(ctx context.Context, symbols []string, failureThreshold int, cooldown time.Duration) (map[string]float64, error) {
+	result, err := p.FetchUSDPrices(ctx, symbols)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err != nil {
+		p.health.ConsecutiveFailures++
+		p.health.LastError = err.Error()
+		if p.health.ConsecutiveFailures >= failureThreshold {
+			p.health.Healthy = false
+			p.health.UnhealthyUntil = time.Now().Add(cooldown).Unix()
+		}
+		log.Printf("Price provider %s failed %d times: %s", p.Name(), p.health.ConsecutiveFailures, err)
+		return nil, fmt.Errorf("%s: %w", p.Name(), err)
+	}
+	p.health.Healthy = true
+	p.health.ConsecutiveFailures = 0
+	p.health.UnhealthyUntil = 0
+	p.health.LastSuccessAt = time.Now().Unix()
+	return result, nil
+}
+
+func median(values []float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
@@ -0,0 +1,199 @@
+package prices
+
+import (
+	"context"
+	"fmt"
+	"game-mining-server/configs"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeBinance a binance style ticker endpoint, it responds 500 while failing is set
+type fakeBinance struct {
+	*httptest.Server
+	failing  atomic.Bool
+	requests atomic.Int32
+}
+
+func newFakeBinance(t *testing.T, tickers string) *fakeBinance {
+	f := &fakeBinance{}
+	f.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f.requests.Add(1)
+		if f.failing.Load() {
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+		_, _ = io.WriteString(w, tickers)
+	}))
+	t.Cleanup(f.Close)
+	return f
+}
+
+func binanceTickers(btc, bnb float64) string {
+	return fmt.Sprintf(`[{"symbol":"BTCUSDT","price":"%v"},{"symbol":"BNBUSDT","price":"%v"}]`, btc, bnb)
+}
+
+func newTestPriceService(t *testing.T, strategy string, providers ...*configs.PriceProviderConfig) *Service {
+	t.Helper()
+	s, e := CreatePriceService(&configs.PriceConfig{Strategy: strategy, Providers: providers, FailureThreshold: 2, CooldownSec: 60}, nil)
+	if e != nil {
+		t.Fatal(e)
+	}
+	return s
+}
+
+func TestMedianPrices(t *testing.T) {
+	a := newFakeBinance(t, binanceTickers(100, 10))
+	b := newFakeBinance(t, binanceTickers(102, 12))
+	c := newFakeBinance(t, binanceTickers(110, 11))
+	gecko := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, `{"bitcoin":{"usd":101}}`)
+	}))
+	defer gecko.Close()
+
+	s := newTestPriceService(t, configs.PriceStrategyMedian,
+		&configs.PriceProviderConfig{Name: "a", Type: configs.PriceProviderBinance, BaseUrl: a.URL},
+		&configs.PriceProviderConfig{Name: "b", Type: configs.PriceProviderBinance, BaseUrl: b.URL},
+		&configs.PriceProviderConfig{Name: "c", Type: configs.PriceProviderBinance, BaseUrl: c.URL},
+		&configs.PriceProviderConfig{Name: "gecko", Type: configs.PriceProviderCoinGecko, BaseUrl: gecko.URL, Symbols: map[string]string{"btc": "bitcoin"}},
+	)
+	prices, e := s.GetUSDPrices(context.Background(), []string{"BTC", "BNB", "ETH"})
+	if e != nil {
+		t.Fatal(e)
+	}
+	// BTC has 4 quotes 100, 101, 102, 110, BNB has 3 quotes 10, 11, 12 since coingecko does not map it
+	assertPrices(t, prices, map[string]float64{"BTC": 101.5, "BNB": 11})
+
+	// a failing provider is left out of the median
+	c.failing.Store(true)
+	prices, e = s.GetUSDPrices(context.Background(), []string{"BTC", "BNB"})
+	if e != nil {
+		t.Fatal(e)
+	}
+	assertPrices(t, prices, map[string]float64{"BTC": 101, "BNB": 11})
+}
+
+func TestMedianPricesAllFailed(t *testing.T) {
+	a := newFakeBinance(t, binanceTickers(100, 10))
+	a.failing.Store(true)
+	s := newTestPriceService(t, configs.PriceStrategyMedian,
+		&configs.PriceProviderConfig{Name: "a", Type: configs.PriceProviderBinance, BaseUrl: a.URL})
+	if _, e := s.GetUSDPrices(context.Background(), []string{"BTC"}); e == nil {
+		t.Fatal("want error when every provider failed")
+	}
+}
+
+func TestFallbackPrices(t *testing.T) {
+	primary := newFakeBinance(t, `[{"symbol":"BTCUSDT","price":"100"}]`)
+	secondary := newFakeBinance(t, binanceTickers(200, 20))
+	s := newTestPriceService(t, configs.PriceStrategyFallback,
+		&configs.PriceProviderConfig{Name: "primary", Type: configs.PriceProviderBinance, BaseUrl: primary.URL},
+		&configs.PriceProviderConfig{Name: "secondary", Type: configs.PriceProviderBinance, BaseUrl: secondary.URL},
+	)
+
+	// symbols missing from the first provider are asked from the next one
+	prices, e := s.GetUSDPrices(context.Background(), []string{"BTC", "BNB"})
+	if e != nil {
+		t.Fatal(e)
+	}
+	assertPrices(t, prices, map[string]float64{"BTC": 100, "BNB": 20})
+
+	// the next provider answers everything when the first one errors
+	primary.failing.Store(true)
+	prices, e = s.GetUSDPrices(context.Background(), []string{"BTC", "BNB"})
+	if e != nil {
+		t.Fatal(e)
+	}
+	assertPrices(t, prices, map[string]float64{"BTC": 200, "BNB": 20})
+}
+
+func TestProviderHealth(t *testing.T) {
+	flaky := newFakeBinance(t, binanceTickers(100, 10))
+	stable := newFakeBinance(t, binanceTickers(102, 12))
+	s := newTestPriceService(t, configs.PriceStrategyMedian,
+		&configs.PriceProviderConfig{Name: "flaky", Type: configs.PriceProviderBinance, BaseUrl: flaky.URL},
+		&configs.PriceProviderConfig{Name: "stable", Type: configs.PriceProviderBinance, BaseUrl: stable.URL},
+	)
+	ctx := context.Background()
+
+	flaky.failing.Store(true)
+	for i := 0; i < 2; i++ {
+		if _, e := s.GetUSDPrices(ctx, []string{"BTC"}); e != nil {
+			t.Fatal(e)
+		}
+	}
+	health := s.Health()[0]
+	if health.Healthy || health.ConsecutiveFailures != 2 || health.UnhealthyUntil <= time.Now().Unix() || health.LastError == "" {
+		t.Fatalf("want flaky unhealthy after 2 failures, got %+v", health)
+	}
+
+	// skipped during cooldown, even after upstream recovers
+	flaky.failing.Store(false)
+	requests := flaky.requests.Load()
+	prices, e := s.GetUSDPrices(ctx, []string{"BTC"})
+	if e != nil {
+		t.Fatal(e)
+	}
+	assertPrices(t, prices, map[string]float64{"BTC": 102})
+	if flaky.requests.Load() != requests {
+		t.Fatal("unhealthy provider should not be asked during cooldown")
+	}
+
+	// asked again once cooldown is over, and healthy after a success
+	s.providers[0].mu.Lock()
+	s.providers[0].health.UnhealthyUntil = time.Now().Add(-time.Second).Unix()
+	s.providers[0].mu.Unlock()
+	prices, e = s.GetUSDPrices(ctx, []string{"BTC"})
+	if e != nil {
+		t.Fatal(e)
+	}
+	assertPrices(t, prices, map[string]float64{"BTC": 101})
+	health = s.Health()[0]
+	if !health.Healthy || health.ConsecutiveFailures != 0 || health.UnhealthyUntil != 0 || health.LastSuccessAt == 0 {
+		t.Fatalf("want flaky recovered, got %+v", health)
+	}
+}
+
+func TestAllProvidersInCooldownAreAsked(t *testing.T) {
+	a := newFakeBinance(t, binanceTickers(100, 10))
+	s := newTestPriceService(t, configs.PriceStrategyFallback,
+		&configs.PriceProviderConfig{Name: "a", Type: configs.PriceProviderBinance, BaseUrl: a.URL})
+	a.failing.Store(true)
+	for i := 0; i < 2; i++ {
+		_, _ = s.GetUSDPrices(context.Background(), []string{"BTC"})
+	}
+	if s.Health()[0].Healthy {
+		t.Fatal("want provider unhealthy")
+	}
+
+	// with every provider in cooldown they are all tried rather than failing without a request
+	a.failing.Store(false)
+	prices, e := s.GetUSDPrices(context.Background(), []string{"BTC"})
+	if e != nil {
+		t.Fatal(e)
+	}
+	assertPrices(t, prices, map[string]float64{"BTC": 100})
+	if !s.Health()[0].Healthy {
+		t.Fatal("want provider recovered")
+	}
+}
+
+func TestMedian(t *testing.T) {
+	cases := []struct {
+		values []float64
+		want   float64
+	}{
+		{[]float64{5}, 5},
+		{[]float64{3, 1, 2}, 2},
+		{[]float64{4, 1, 3, 2}, 2.5},
+	}
+	for _, c := range cases {
+		if got := median(c.values); got != c.want {
+			t.Errorf("median(%v): want %v, got %v", c.values, c.want, got)
+		}
+	}
+}
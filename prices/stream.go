@@ -0,0 +1,111 @@
+package prices
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+)
+
+const defaultStreamRefreshPeriod = 5 * time.Second
+
+// Subscription price updates of a set of symbols, C receives usd prices of symbols which changed since last update
+type Subscription struct {
+	C       chan map[string]float64
+	symbols []string
+	last    map[string]float64 // last pushed prices, only accessed by hub loop
+}
+
+// Hub fan out prices to stream subscribers, symbols of all subscribers are fetched together in each period,
+// so the number of clients never changes the number of upstream requests
+type Hub struct {
+	service *Service
+	period  time.Duration
+
+	mu   sync.Mutex
+	subs map[*Subscription]bool
+}
+
+func newHub(service *Service, period time.Duration) *Hub {
+	return &Hub{service: service, period: period, subs: make(map[*Subscription]bool)}
+}
+
+// Subscribe start receiving updates of symbols, initial are the prices the subscriber already has
+func (h *Hub) Subscribe(symbols []string, initial map[string]float64) *Subscription {
+	sub := &Subscription{C: make(chan map[string]float64, 1), symbols: symbols, last: make(map[string]float64)}
+	for symbol, price := range initial {
+		sub.last[symbol] = price
+	}
+	h.mu.Lock()
+	h.subs[sub] = true
+	h.mu.Unlock()
+	return sub
+}
+
+// Unsubscribe stop receiving updates
+func (h *Hub) Unsubscribe(sub *Subscription) {
+	h.mu.Lock()
+	delete(h.subs, sub)
+	h.mu.Unlock()
+}
+
+func (h *Hub) run() {
+	ticker := time.NewTicker(h.period)
+	defer ticker.Stop()
+	for range ticker.C {
+		h.push()
+	}
+}
+
+// push fetch prices of all subscribed symbols once and send each subscriber the ones it asked for that changed
+func (h *Hub) push() {
+	h.mu.Lock()
+	subs := make([]*Subscription, 0, len(h.subs))
+	symbolSet := make(map[string]bool)
+	for sub := range h.subs {
+		subs = append(subs, sub)
+		for _, symbol := range sub.symbols {
+			symbolSet[symbol] = true
+		}
+	}
+	h.mu.Unlock()
+	if len(subs) == 0 {
+		return
+	}
+
+	symbols := make([]string, 0, len(symbolSet))
+	for symbol := range symbolSet {
+		symbols = append(symbols, symbol)
+	}
+	prices, e0 := h.service.GetCachedUSDPrices(context.Background(), symbols)
+	if e0 != nil {
+		log.Printf("Price stream fetch %d symbols failed: %s", len(symbols), e0)
+		return
+	}
+
+	for _, sub := range subs {
+		changed := make(map[string]float64)
+		for _, symbol := range sub.symbols {
+			if price, ok := prices[symbol]; ok && sub.last[symbol] != price {
+				changed[symbol] = price
+				sub.last[symbol] = price
+			}
+		}
+		if len(changed) == 0 {
+			continue
+		}
+		// a slow client gets the latest update merged with the one it has not read yet
+		select {
+		case pending := <-sub.C:
+			for symbol, price := range changed {
+				pending[symbol] = price
+			}
+			changed = pending
+		default:
+		}
+		select {
+		case sub.C <- changed:
+		default:
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package proxies
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// conditionalHeaders client validators, replaced by cached validators when revalidating upstream
+var conditionalHeaders = []string{"If-None-Match", "If-Modified-Since", "If-Match", "If-Unmodified-Since", "If-Range"}
+
+// ParseCacheControl parse Cache-Control directives, keys are lower case, values are unquoted
+func ParseCacheControl(header http.Header) map[string]string {
+	directives := make(map[string]string)
+	for _, value := range header.Values("Cache-Control") {
+		for _, part := range strings.Split(value, ",") {
+			key, val, _ := strings.Cut(strings.TrimSpace(part), "=")
+			if key != "" {
+				directives[strings.ToLower(key)] = strings.Trim(val, `"`)
+			}
+		}
+	}
+	return directives
+}
+
+// IsCacheableRequest only plain GET requests of assets are served from cache
+func IsCacheableRequest(req *http.Request) bool {
+	if req.Method != http.MethodGet || req.Header.Get("Range") != "" || req.Header.Get("Authorization") != "" {
+		return false
+	}
+	_, noStore := ParseCacheControl(req.Header)["no-store"]
+	return !noStore
+}
+
+// CacheEncoding pick the encoding a cached variant is fetched with from client Accept-Encoding
+func CacheEncoding(req *http.Request) string {
+	accept := strings.ToLower(req.Header.Get("Accept-Encoding"))
+	switch {
+	case strings.Contains(accept, "br"):
+		return "br"
+	case strings.Contains(accept, "gzip"):
+		return "gzip"
+	default:
+		return "identity"
+	}
+}
+
+// PrepareCacheRequest make the upstream request independent of client validators and encodings,
+// so its response can be shared by all clients of the same cache variant
+func PrepareCacheRequest(req *http.Request, encoding string) {
+	for _, key := range conditionalHeaders {
+		req.Header.Del(key)
+	}
+	req.Header.Del("Cookie")
+	req.Header.Set("Accept-Encoding", encoding)
+}
+
+// SetValidators ask upstream to revalidate a cached response
+func SetValidators(req *http.Request, cached http.Header) {
+	if etag := cached.Get("ETag"); etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+	if lastModified := cached.Get("Last-Modified"); lastModified != "" {
+		req.Header.Set("If-Modified-Since", lastModified)
+	}
+}
+
+// HasValidators whether a cached response can be revalidated
+func HasValidators(header http.Header) bool {
+	return header.Get("ETag") != "" || header.Get("Last-Modified") != ""
+}
+
+// Freshness return how long a response may be served without revalidation, and whether it may be stored at all.
+// Responses without explicit freshness are stored only if they carry validators.
+func Freshness(res *http.Response, now time.Time) (time.Duration, bool) {
+	if res.StatusCode != http.StatusOK || res.Header.Get("Set-Cookie") != "" {
+		return 0, false
+	}
+	for _, vary := range res.Header.Values("Vary") {
+		for _, field := range strings.Split(vary, ",") {
+			if field = strings.TrimSpace(field); field != "" && !strings.EqualFold(field, "Accept-Encoding") {
+				return 0, false
+			}
+		}
+	}
+
+	directives := ParseCacheControl(res.Header)
+	if _, ok := directives["no-store"]; ok {
+		return 0, false
+	}
+	if _, ok := directives["private"]; ok {
+		return 0, false
+	}
+	if _, ok := directives["no-cache"]; ok {
+		return 0, HasValidators(res.Header)
+	}
+	for _, key := range []string{"s-maxage", "max-age"} {
+		if val, ok := directives[key]; ok {
+			if sec, e0 := strconv.ParseInt(val, 10, 64); e0 == nil && sec > 0 {
+				return time.Duration(sec) * time.Second, true
+			}
+			return 0, HasValidators(res.Header)
+		}
+	}
+	if expires, e0 := http.ParseTime(res.Header.Get("Expires")); e0 == nil {
+		date, e1 := http.ParseTime(res.Header.Get("Date"))
+		if e1 != nil {
+			date = now
+		}
+		if fresh := expires.Sub(date); fresh > 0 {
+			return fresh, true
+		}
+	}
+	return 0, HasValidators(res.Header)
+}
+
+// NotModified evaluate client conditional headers against a response header, If-None-Match takes precedence
+func NotModified(req *http.Request, header http.Header) bool {
+	if inm := req.Header.Get("If-None-Match"); inm != "" {
+		etag := strings.TrimPrefix(header.Get("ETag"), "W/")
+		if etag == "" {
+			return false
+		}
+		for _, candidate := range strings.Split(inm, ",") {
+			candidate = strings.TrimSpace(candidate)
+			if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+				return true
+			}
+		}
+		return false
+	}
+	since, e0 := http.ParseTime(req.Header.Get("If-Modified-Since"))
+	lastModified, e1 := http.ParseTime(header.Get("Last-Modified"))
+	return e0 == nil && e1 == nil && !lastModified.After(since)
+}
@@ -0,0 +1,135 @@
+package proxies
+
+import (
+	"errors"
+	"fmt"
+	"game-mining-server/configs"
+	"net"
+	"net/netip"
+	"net/url"
+	"strings"
+	"syscall"
+)
+
+var (
+	ErrForbiddenScheme  = errors.New("proxy scheme not allowed")
+	ErrForbiddenHost    = errors.New("proxy host not allowed")
+	ErrForbiddenAddress = errors.New("proxy address not allowed")
+)
+
+var defaultAllowedSchemes = []string{"http", "https"}
+
+// reservedPrefixes special purpose networks which are not covered by netip.Addr helpers
+var reservedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),       // "this" network
+	netip.MustParsePrefix("100.64.0.0/10"),   // carrier grade NAT
+	netip.MustParsePrefix("192.0.0.0/24"),    // IETF protocol assignments
+	netip.MustParsePrefix("192.0.2.0/24"),    // documentation
+	netip.MustParsePrefix("198.18.0.0/15"),   // benchmarking
+	netip.MustParsePrefix("198.51.100.0/24"), // documentation
+	netip.MustParsePrefix("203.0.113.0/24"),  // documentation
+	netip.MustParsePrefix("240.0.0.0/4"),     // reserved and broadcast
+	netip.MustParsePrefix("64:ff9b::/96"),    // NAT64, may map to private ipv4
+	netip.MustParsePrefix("2001:db8::/32"),   // documentation
+}
+
+// Guard validate proxy target urls and dialed addresses to prevent requests to internal network
+type Guard struct {
+	allowedHosts        []string
+	allowedSchemes      []string
+	allowPrivateNetwork bool
+}
+
+func NewGuard(cfg *configs.ProxyConfig) *Guard {
+	guard := &Guard{allowedSchemes: defaultAllowedSchemes}
+	if cfg == nil {
+		return guard
+	}
+	for _, host := range cfg.AllowedHosts {
+		guard.allowedHosts = append(guard.allowedHosts, strings.ToLower(strings.TrimSpace(host)))
+	}
+	if len(cfg.AllowedSchemes) > 0 {
+		guard.allowedSchemes = cfg.AllowedSchemes
+	}
+	guard.allowPrivateNetwork = cfg.AllowPrivateNetwork
+	return guard
+}
+
+// CheckURL check url scheme and host against allowlist, addresses are checked again when dialing
+func (g *Guard) CheckURL(u *url.URL) error {
+	if !g.isSchemeAllowed(strings.ToLower(u.Scheme)) {
+		return fmt.Errorf("%w: %s", ErrForbiddenScheme, u.Scheme)
+	}
+	if u.User != nil {
+		return fmt.Errorf("%w: userinfo is not allowed", ErrForbiddenHost)
+	}
+	host := strings.ToLower(u.Hostname())
+	if host == "" || !g.isHostAllowed(host) {
+		return fmt.Errorf("%w: %s", ErrForbiddenHost, host)
+	}
+	// reject ip literal early, domain names are resolved and checked in DialControl
+	if addr, e := netip.ParseAddr(host); e == nil {
+		return g.checkAddr(addr)
+	}
+	return nil
+}
+
+// DialControl net.Dialer control hook, called with the resolved ip before connecting, so dns rebinding is covered
+func (g *Guard) DialControl(_ string, address string, _ syscall.RawConn) error {
+	host, _, e0 := net.SplitHostPort(address)
+	if e0 != nil {
+		return e0
+	}
+	addr, e1 := netip.ParseAddr(host)
+	if e1 != nil {
+		return fmt.Errorf("%w: %s", ErrForbiddenAddress, host)
+	}
+	return g.checkAddr(addr)
+}
+
+func (g *Guard) checkAddr(addr netip.Addr) error {
+	if g.allowPrivateNetwork || isPublicAddr(addr) {
+		return nil
+	}
+	return fmt.Errorf("%w: %s", ErrForbiddenAddress, addr)
+}
+
+func (g *Guard) isSchemeAllowed(scheme string) bool {
+	for _, allowed := range g.allowedSchemes {
+		if scheme == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// isHostAllowed empty allowlist allows all hosts, "example.com" matches exactly, "*.example.com" matches subdomains
+func (g *Guard) isHostAllowed(host string) bool {
+	if len(g.allowedHosts) == 0 {
+		return true
+	}
+	for _, allowed := range g.allowedHosts {
+		if suffix, ok := strings.CutPrefix(allowed, "*"); ok {
+			if strings.HasSuffix(host, suffix) {
+				return true
+			}
+		} else if host == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+func isPublicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsValid() || addr.IsUnspecified() || addr.IsLoopback() || addr.IsPrivate() ||
+		addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() || addr.IsInterfaceLocalMulticast() || addr.IsMulticast() {
+		return false
+	}
+	for _, prefix := range reservedPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
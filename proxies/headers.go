@@ -0,0 +1,47 @@
+package proxies
+
+import (
+	"net/http"
+	"net/textproto"
+	"strings"
+)
+
+// hopHeaders hop-by-hop headers, they are meaningful only for a single connection and must not be forwarded
+var hopHeaders = []string{
+	"Connection",
+	"Proxy-Connection",
+	"Keep-Alive",
+	"Proxy-Authenticate",
+	"Proxy-Authorization",
+	"Te",
+	"Trailer",
+	"Transfer-Encoding",
+	"Upgrade",
+}
+
+// CopyHeaders copy all header values from src to dst, hop-by-hop headers and ignored keys are skipped
+func CopyHeaders(dst http.Header, src http.Header, ignoreKeys ...string) {
+	skipped := make(map[string]bool)
+	for _, key := range hopHeaders {
+		skipped[key] = true
+	}
+	for _, key := range ignoreKeys {
+		skipped[textproto.CanonicalMIMEHeaderKey(key)] = true
+	}
+	// headers listed in Connection are also hop-by-hop
+	for _, value := range src.Values("Connection") {
+		for _, key := range strings.Split(value, ",") {
+			if key = strings.TrimSpace(key); key != "" {
+				skipped[textproto.CanonicalMIMEHeaderKey(key)] = true
+			}
+		}
+	}
+	for key, values := range src {
+		if skipped[textproto.CanonicalMIMEHeaderKey(key)] {
+			continue
+		}
+		for _, value := range values {
+			dst.Add(key, value)
+		}
+	}
+}
@@ -0,0 +1,225 @@
+package proxies
+
+import (
+	"bufio"
+	"html"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	xhtml "golang.org/x/net/html"
+)
+
+// FrameBlockingHeaders response headers which stop proxied pages from being embedded in the mini app
+var FrameBlockingHeaders = []string{
+	"content-security-policy",
+	"content-security-policy-report-only",
+	"x-frame-options",
+	"cross-origin-opener-policy",
+	"cross-origin-embedder-policy",
+	"referrer-policy",
+}
+
+// urlAttrs attributes holding a single url, keyed by attribute name
+var urlAttrs = map[string]bool{
+	"src":        true,
+	"href":       true,
+	"action":     true,
+	"formaction": true,
+	"poster":     true,
+	"data":       true,
+	"background": true,
+}
+
+var cssUrlPattern = regexp.MustCompile(`url\(\s*(['"]?)([^'")]+)(['"]?)\s*\)`)
+
+// StripFrameBlockingHeaders remove headers and CSP which forbid embedding the page
+func StripFrameBlockingHeaders(header http.Header) {
+	for _, key := range FrameBlockingHeaders {
+		header.Del(key)
+	}
+}
+
+// HtmlRewriter rewrite links of a proxied html page to absolute upstream urls while streaming
+type HtmlRewriter struct {
+	base            *url.URL // page url, replaced by <base href> when present
+	origin          string   // scheme://host of the page, used for inline script paths
+	rewritePaths    []string // root relative paths replaced in inline scripts
+	injectScriptUrl string
+	bannerHtml      string
+}
+
+// NewHtmlRewriter create a rewriter of page pageUrl
+func (s *Service) NewHtmlRewriter(pageUrl *url.URL) *HtmlRewriter {
+	rewritePaths := DefaultRewritePaths
+	if site := s.SiteByHost(pageUrl.Host); site != nil {
+		rewritePaths = site.RewritePaths
+	}
+	return &HtmlRewriter{
+		base:            pageUrl,
+		origin:          pageUrl.Scheme + "://" + pageUrl.Host,
+		rewritePaths:    rewritePaths,
+		injectScriptUrl: s.InjectScriptUrl,
+		bannerHtml:      s.BannerHtml,
+	}
+}
+
+// Rewrite tokenize html from src and write the rewritten page to dst, tokens are written as soon as they are read
+func (r *HtmlRewriter) Rewrite(dst io.Writer, src io.Reader) error {
+	w := bufio.NewWriter(dst)
+	z := xhtml.NewTokenizer(src)
+	scriptInjected := r.injectScriptUrl == ""
+	bannerInjected := r.bannerHtml == ""
+	rawTag := "" // script or style whose content is the next text token
+
+	for {
+		tt := z.Next()
+		textOf := rawTag
+		rawTag = ""
+		switch tt {
+		case xhtml.ErrorToken:
+			if !scriptInjected {
+				_, _ = w.WriteString(r.scriptTag())
+			}
+			if err := z.Err(); err != io.EOF {
+				_ = w.Flush()
+				return err
+			}
+			return w.Flush()
+		case xhtml.StartTagToken, xhtml.SelfClosingTagToken:
+			raw := string(z.Raw())
+			tok := z.Token()
+			if tok.Data == "meta" && isCspMeta(tok) {
+				continue
+			}
+			if tok.Data == "body" && !scriptInjected {
+				_, _ = w.WriteString(r.scriptTag())
+				scriptInjected = true
+			}
+			if r.rewriteAttrs(&tok) {
+				_, _ = w.WriteString(tok.String())
+			} else {
+				_, _ = w.WriteString(raw)
+			}
+			if tok.Data == "body" && !bannerInjected {
+				_, _ = w.WriteString(r.bannerHtml)
+				bannerInjected = true
+			}
+			if tt == xhtml.StartTagToken && (tok.Data == "style" || tok.Data == "script" && getAttr(tok, "src") == "") {
+				rawTag = tok.Data
+			}
+		case xhtml.EndTagToken:
+			if name, _ := z.TagName(); string(name) == "head" && !scriptInjected {
+				_, _ = w.WriteString(r.scriptTag())
+				scriptInjected = true
+			}
+			_, _ = w.Write(z.Raw())
+		case xhtml.TextToken:
+			// script and style contents are raw text, never escaped
+			switch textOf {
+			case "style":
+				_, _ = w.WriteString(r.rewriteCss(string(z.Raw())))
+			case "script":
+				_, _ = w.WriteString(r.rewriteScript(string(z.Raw())))
+			default:
+				_, _ = w.Write(z.Raw())
+			}
+		default:
+			_, _ = w.Write(z.Raw())
+		}
+	}
+}
+
+// rewriteAttrs rewrite url attributes of a tag, return whether any attribute changed
+func (r *HtmlRewriter) rewriteAttrs(tok *xhtml.Token) bool {
+	changed := false
+	for i := range tok.Attr {
+		attr := &tok.Attr[i]
+		val := attr.Val
+		switch {
+		case tok.Data == "base" && attr.Key == "href":
+			if baseUrl, e0 := r.base.Parse(strings.TrimSpace(attr.Val)); e0 == nil {
+				r.base = baseUrl
+				val = baseUrl.String()
+			}
+		case urlAttrs[attr.Key]:
+			val = r.resolve(attr.Val)
+		case attr.Key == "srcset" || attr.Key == "imagesrcset":
+			val = r.rewriteSrcset(attr.Val)
+		case attr.Key == "style":
+			val = r.rewriteCss(attr.Val)
+		}
+		if val != attr.Val {
+			attr.Val = val
+			changed = true
+		}
+	}
+	return changed
+}
+
+// resolve make a link absolute against page base, data, javascript and fragment links are kept
+func (r *HtmlRewriter) resolve(raw string) string {
+	val := strings.TrimSpace(raw)
+	if val == "" || strings.HasPrefix(val, "#") {
+		return raw
+	}
+	if i := strings.IndexByte(val, ':'); i > 0 && !strings.ContainsAny(val[:i], "/?#") {
+		// already has a scheme, e.g. https:, data:, javascript:, mailto:
+		return raw
+	}
+	resolved, e0 := r.base.Parse(val)
+	if e0 != nil {
+		return raw
+	}
+	return resolved.String()
+}
+
+func (r *HtmlRewriter) rewriteSrcset(srcset string) string {
+	candidates := strings.Split(srcset, ",")
+	for i, candidate := range candidates {
+		fields := strings.Fields(candidate)
+		if len(fields) == 0 {
+			continue
+		}
+		fields[0] = r.resolve(fields[0])
+		candidates[i] = strings.Join(fields, " ")
+	}
+	return strings.Join(candidates, ", ")
+}
+
+func (r *HtmlRewriter) rewriteCss(css string) string {
+	return cssUrlPattern.ReplaceAllStringFunc(css, func(match string) string {
+		groups := cssUrlPattern.FindStringSubmatch(match)
+		return "url(" + groups[1] + r.resolve(groups[2]) + groups[3] + ")"
+	})
+}
+
+// rewriteScript make configured root relative paths in script string literals absolute, e.g. "/_next/static
+func (r *HtmlRewriter) rewriteScript(script string) string {
+	for _, path := range r.rewritePaths {
+		for _, quote := range []string{`"`, `'`, "`"} {
+			script = strings.ReplaceAll(script, quote+path, quote+r.origin+path)
+		}
+	}
+	return script
+}
+
+func (r *HtmlRewriter) scriptTag() string {
+	return `<script src="` + html.EscapeString(r.injectScriptUrl) + `"></script>`
+}
+
+func isCspMeta(tok xhtml.Token) bool {
+	equiv := strings.ToLower(getAttr(tok, "http-equiv"))
+	return equiv == "content-security-policy" || equiv == "content-security-policy-report-only" || equiv == "x-frame-options"
+}
+
+func getAttr(tok xhtml.Token, key string) string {
+	for _, attr := range tok.Attr {
+		if attr.Key == key {
+			return attr.Val
+		}
+	}
+	return ""
+}
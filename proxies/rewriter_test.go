@@ -0,0 +1,100 @@
+package proxies
+
+import (
+	"bytes"
+	"flag"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update golden files in testdata")
+
+const testPageUrl = "https://app.example.com/dir/page.html"
+
+func newTestRewriter(t *testing.T, injectScriptUrl, bannerHtml string) *HtmlRewriter {
+	t.Helper()
+	pageUrl, e := url.Parse(testPageUrl)
+	if e != nil {
+		t.Fatal(e)
+	}
+	s := newTestService(t, nil)
+	s.InjectScriptUrl = injectScriptUrl
+	s.BannerHtml = bannerHtml
+	return s.NewHtmlRewriter(pageUrl)
+}
+
+// assertGolden rewrite testdata/name.html and compare with testdata/name.golden, run with -update to regenerate
+func assertGolden(t *testing.T, name string, rewriter *HtmlRewriter) {
+	t.Helper()
+	input, e0 := os.ReadFile(filepath.Join("testdata", name+".html"))
+	if e0 != nil {
+		t.Fatal(e0)
+	}
+	var out bytes.Buffer
+	if e1 := rewriter.Rewrite(&out, bytes.NewReader(input)); e1 != nil {
+		t.Fatalf("rewrite %s: %s", name, e1)
+	}
+
+	goldenPath := filepath.Join("testdata", name+".golden")
+	if *update {
+		if e2 := os.WriteFile(goldenPath, out.Bytes(), 0644); e2 != nil {
+			t.Fatal(e2)
+		}
+		return
+	}
+	want, e3 := os.ReadFile(goldenPath)
+	if e3 != nil {
+		t.Fatal(e3)
+	}
+	if !bytes.Equal(out.Bytes(), want) {
+		t.Errorf("%s mismatch\n--- got ---\n%s\n--- want ---\n%s", name, out.Bytes(), want)
+	}
+}
+
+func TestRewriterGolden(t *testing.T) {
+	const script = "https://game.example.com/inject.js?v=1&t=2"
+	const banner = `<div id="proxy-banner">Back to game</div>`
+	cases := []struct {
+		name            string
+		injectScriptUrl string
+		bannerHtml      string
+	}{
+		{name: "attrs"},  // src, href, srcset, action, poster and style url()
+		{name: "base"},   // <base href> changes the base of following links
+		{name: "csp"},    // csp and frame options meta tags are removed
+		{name: "inline"}, // style and script contents
+		{name: "inject", injectScriptUrl: script, bannerHtml: banner},          // script before </head>, banner after <body>
+		{name: "inject_nohead", injectScriptUrl: script, bannerHtml: banner},   // script before <body> without head
+		{name: "inject_fragment", injectScriptUrl: script, bannerHtml: banner}, // script at the end without head and body
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			assertGolden(t, c.name, newTestRewriter(t, c.injectScriptUrl, c.bannerHtml))
+		})
+	}
+}
+
+func TestStripFrameBlockingHeaders(t *testing.T) {
+	header := http.Header{}
+	header.Set("Content-Security-Policy", "frame-ancestors 'none'")
+	header.Set("Content-Security-Policy-Report-Only", "default-src 'self'")
+	header.Set("X-Frame-Options", "DENY")
+	header.Set("Cross-Origin-Opener-Policy", "same-origin")
+	header.Set("Cross-Origin-Embedder-Policy", "require-corp")
+	header.Set("Referrer-Policy", "no-referrer")
+	header.Set("Content-Type", "text/html")
+	header.Set("Cache-Control", "no-cache")
+
+	StripFrameBlockingHeaders(header)
+	for _, key := range FrameBlockingHeaders {
+		if v := header.Get(key); v != "" {
+			t.Errorf("%s should be removed, got %q", key, v)
+		}
+	}
+	if header.Get("Content-Type") != "text/html" || header.Get("Cache-Control") != "no-cache" {
+		t.Errorf("other headers should be kept, got %v", header)
+	}
+}
@@ -0,0 +1,208 @@
+package proxies
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"game-mining-server/configs"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	maxRedirects                = 5
+	defaultConnectTimeout       = 10 * time.Second
+	defaultReadTimeout          = 30 * time.Second
+	defaultMaxRequestBodyBytes  = int64(1 << 20)
+	defaultMaxResponseBodyBytes = int64(20 << 20)
+	defaultAssetCacheMaxAge     = 24 * time.Hour
+	defaultAssetCacheMaxEntry   = int64(2 << 20)
+	defaultWsIdleTimeout        = 2 * time.Minute
+	defaultWsMaxConnsPerIp      = 10
+)
+
+var ErrResponseTooLarge = errors.New("proxy response body too large")
+
+type Service struct {
+	Guard                *Guard       // target url and address validator
+	Client               *http.Client // shared http client which only connects to addresses allowed by guard
+	Sites                []*Site      // embedded dApp sites
+	InjectScriptUrl      string       // script injected into proxied html pages
+	BannerHtml           string       // banner injected into proxied html pages
+	AssetCacheEnabled    bool         // cache embedded site assets in redis
+	AssetCacheMaxAge     time.Duration
+	AssetCacheMaxEntry   int64
+	WsIdleTimeout        time.Duration
+	WsConns              *ConnLimiter // concurrent websockets per client ip
+	ReadTimeout          time.Duration
+	MaxRequestBodyBytes  int64
+	MaxResponseBodyBytes int64
+}
+
+func CreateProxyService(cfg *configs.ProxyConfig) (*Service, error) {
+	s := &Service{
+		Guard:                NewGuard(cfg),
+		ReadTimeout:          defaultReadTimeout,
+		MaxRequestBodyBytes:  defaultMaxRequestBodyBytes,
+		MaxResponseBodyBytes: defaultMaxResponseBodyBytes,
+		AssetCacheEnabled:    true,
+		AssetCacheMaxAge:     defaultAssetCacheMaxAge,
+		AssetCacheMaxEntry:   defaultAssetCacheMaxEntry,
+		WsIdleTimeout:        defaultWsIdleTimeout,
+	}
+	wsMaxConnsPerIp := defaultWsMaxConnsPerIp
+	connectTimeout := defaultConnectTimeout
+	var siteCfgs []*configs.ProxySiteConfig
+	if cfg != nil {
+		siteCfgs = cfg.Sites
+		s.InjectScriptUrl = cfg.InjectScriptUrl
+		s.BannerHtml = cfg.BannerHtml
+		if cfg.ConnectTimeoutSec > 0 {
+			connectTimeout = time.Duration(cfg.ConnectTimeoutSec) * time.Second
+		}
+		if cfg.ReadTimeoutSec > 0 {
+			s.ReadTimeout = time.Duration(cfg.ReadTimeoutSec) * time.Second
+		}
+		if cfg.MaxRequestBodyBytes > 0 {
+			s.MaxRequestBodyBytes = cfg.MaxRequestBodyBytes
+		}
+		if cfg.MaxResponseBodyBytes > 0 {
+			s.MaxResponseBodyBytes = cfg.MaxResponseBodyBytes
+		}
+		s.AssetCacheEnabled = !cfg.DisableAssetCache
+		if cfg.AssetCacheMaxAgeSec > 0 {
+			s.AssetCacheMaxAge = time.Duration(cfg.AssetCacheMaxAgeSec) * time.Second
+		}
+		if cfg.AssetCacheMaxEntryBytes > 0 {
+			s.AssetCacheMaxEntry = cfg.AssetCacheMaxEntryBytes
+		}
+		if cfg.WsIdleTimeoutSec > 0 {
+			s.WsIdleTimeout = time.Duration(cfg.WsIdleTimeoutSec) * time.Second
+		}
+		if cfg.WsMaxConnsPerIp > 0 {
+			wsMaxConnsPerIp = cfg.WsMaxConnsPerIp
+		}
+	}
+
+	sites, e0 := newSites(siteCfgs)
+	if e0 != nil {
+		return nil, e0
+	}
+	s.Sites = sites
+	s.WsConns = NewConnLimiter(wsMaxConnsPerIp)
+
+	dialer := &net.Dialer{
+		Timeout:   connectTimeout,
+		KeepAlive: 30 * time.Second,
+		Control:   s.Guard.DialControl,
+	}
+	transport := &http.Transport{
+		Proxy:                 nil, // never use env proxy, it would bypass address checks
+		DialContext:           dialer.DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          200,
+		MaxIdleConnsPerHost:   32,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   connectTimeout,
+		ResponseHeaderTimeout: s.ReadTimeout,
+		ExpectContinueTimeout: time.Second,
+		DisableCompression:    true, // keep upstream encoding, body is passed through as is
+	}
+	s.Client = &http.Client{
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxRedirects {
+				return fmt.Errorf("stopped after %d redirects", maxRedirects)
+			}
+			return s.Guard.CheckURL(req.URL)
+		},
+	}
+	return s, nil
+}
+
+// Do send request with shared client, the response body is canceled if no data is read within read timeout
+func (s *Service) Do(req *http.Request) (*http.Response, error) {
+	ctx, cancel := context.WithCancel(req.Context())
+	res, err := s.Client.Do(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	res.Body = newIdleTimeoutBody(res.Body, s.ReadTimeout, cancel)
+	// proxied pages are embedded in the mini app, headers forbidding it are dropped for every response
+	StripFrameBlockingHeaders(res.Header)
+	return res, nil
+}
+
+// ReadAllLimited read whole body, return ErrResponseTooLarge if body is larger than max response size
+func (s *Service) ReadAllLimited(body io.Reader) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(body, s.MaxResponseBodyBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > s.MaxResponseBodyBytes {
+		return nil, ErrResponseTooLarge
+	}
+	return data, nil
+}
+
+// LimitReader return a reader failing with ErrResponseTooLarge once more than max response size is read
+func (s *Service) LimitReader(body io.Reader) io.Reader {
+	return &limitedReader{r: body, n: s.MaxResponseBodyBytes}
+}
+
+type limitedReader struct {
+	r io.Reader
+	n int64
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.n < 0 {
+		return 0, ErrResponseTooLarge
+	}
+	if int64(len(p)) > l.n+1 {
+		p = p[:l.n+1]
+	}
+	n, err := l.r.Read(p)
+	l.n -= int64(n)
+	if l.n < 0 {
+		return n + int(l.n), ErrResponseTooLarge
+	}
+	return n, err
+}
+
+// IsForbidden check whether an error is caused by guard
+func IsForbidden(err error) bool {
+	return errors.Is(err, ErrForbiddenScheme) || errors.Is(err, ErrForbiddenHost) || errors.Is(err, ErrForbiddenAddress)
+}
+
+// idleTimeoutBody cancel the request if a single read waits longer than timeout
+type idleTimeoutBody struct {
+	body    io.ReadCloser
+	timer   *time.Timer
+	timeout time.Duration
+	cancel  context.CancelFunc
+	once    sync.Once
+}
+
+func newIdleTimeoutBody(body io.ReadCloser, timeout time.Duration, cancel context.CancelFunc) *idleTimeoutBody {
+	return &idleTimeoutBody{body: body, timer: time.AfterFunc(timeout, cancel), timeout: timeout, cancel: cancel}
+}
+
+func (b *idleTimeoutBody) Read(p []byte) (int, error) {
+	b.timer.Reset(b.timeout)
+	n, err := b.body.Read(p)
+	b.timer.Stop()
+	return n, err
+}
+
+func (b *idleTimeoutBody) Close() error {
+	b.once.Do(func() {
+		b.timer.Stop()
+		b.cancel()
+	})
+	return b.body.Close()
+}
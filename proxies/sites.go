@@ -0,0 +1,166 @@
+package proxies
+
+import (
+	"fmt"
+	"game-mining-server/configs"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"strings"
+)
+
+// DefaultRewritePaths relative paths rewritten in html when the page is not from a configured site
+var DefaultRewritePaths = []string{"/_next", "/manifest.json", "/favicon.ico"}
+
+// reservedSitePrefixes route groups of the server, a site prefix under them would collide with api routes
+var reservedSitePrefixes = []string{"/api", "/proxy", "/wallet", "/rpc", "/bot"}
+
+var defaultSites = []*configs.ProxySiteConfig{
+	{Name: "pancakeswap", Prefix: "/_next", Origin: "https://pancakeswap.finance"},
+}
+
+// Site an embedded dApp site whose assets are served under a route prefix
+type Site struct {
+	Name            string
+	Prefix          string
+	Origin          *url.URL
+	PathRewrites    []*configs.ProxyPathRewrite
+	RewritePaths    []string
+	RequestHeaders  map[string]string
+	ResponseHeaders map[string]string
+}
+
+func newSites(cfgs []*configs.ProxySiteConfig) ([]*Site, error) {
+	if len(cfgs) == 0 {
+		cfgs = defaultSites
+	}
+	sites := make([]*Site, 0, len(cfgs))
+	for _, cfg := range cfgs {
+		origin, e0 := url.Parse(cfg.Origin)
+		if e0 != nil || origin.Scheme == "" || origin.Host == "" {
+			return nil, fmt.Errorf("proxy site %s has invalid origin: %s", cfg.Name, cfg.Origin)
+		}
+		prefix := "/" + strings.Trim(cfg.Prefix, "/")
+		if prefix == "/" {
+			return nil, fmt.Errorf("proxy site %s has empty prefix", cfg.Name)
+		}
+		for _, reserved := range reservedSitePrefixes {
+			if prefixOverlaps(prefix, reserved) {
+				return nil, fmt.Errorf("proxy site %s prefix %s is reserved", cfg.Name, prefix)
+			}
+		}
+		for _, site := range sites {
+			// sites sharing a prefix are told apart by origin, nested prefixes make the router panic
+			if site.Prefix == prefix && strings.EqualFold(site.Origin.Host, origin.Host) {
+				return nil, fmt.Errorf("proxy site %s duplicates prefix %s of site %s", cfg.Name, prefix, site.Name)
+			} else if site.Prefix != prefix && prefixOverlaps(prefix, site.Prefix) {
+				return nil, fmt.Errorf("proxy site %s prefix %s overlaps prefix %s of site %s", cfg.Name, prefix, site.Prefix, site.Name)
+			}
+		}
+		rewritePaths := cfg.RewritePaths
+		if len(rewritePaths) == 0 {
+			rewritePaths = DefaultRewritePaths
+		}
+		sites = append(sites, &Site{
+			Name:            cfg.Name,
+			Prefix:          prefix,
+			Origin:          origin,
+			PathRewrites:    cfg.PathRewrites,
+			RewritePaths:    rewritePaths,
+			RequestHeaders:  cfg.RequestHeaders,
+			ResponseHeaders: cfg.ResponseHeaders,
+		})
+	}
+	return sites, nil
+}
+
+// prefixOverlaps whether route prefixes a and b are the same or one is under the other
+func prefixOverlaps(a string, b string) bool {
+	return a == b || strings.HasPrefix(a, b+"/") || strings.HasPrefix(b, a+"/")
+}
+
+// Prefixes return distinct route prefixes of all sites
+func (s *Service) Prefixes() []string {
+	var prefixes []string
+	seen := make(map[string]bool)
+	for _, site := range s.Sites {
+		if !seen[site.Prefix] {
+			seen[site.Prefix] = true
+			prefixes = append(prefixes, site.Prefix)
+		}
+	}
+	return prefixes
+}
+
+// MatchSite find the site serving a route prefix, if sites share the prefix, the one whose origin matches
+// the page being proxied (the url param in Referer) wins, otherwise the first configured one
+func (s *Service) MatchSite(prefix string, referer string) *Site {
+	var matched []*Site
+	for _, site := range s.Sites {
+		if site.Prefix == prefix {
+			matched = append(matched, site)
+		}
+	}
+	if len(matched) <= 1 || referer == "" {
+		if len(matched) == 0 {
+			return nil
+		}
+		return matched[0]
+	}
+	if refererUrl, e0 := url.Parse(referer); e0 == nil {
+		if pageUrl, e1 := url.Parse(refererUrl.Query().Get("url")); e1 == nil {
+			for _, site := range matched {
+				if strings.EqualFold(site.Origin.Host, pageUrl.Host) {
+					return site
+				}
+			}
+		}
+	}
+	return matched[0]
+}
+
+// SiteByHost find the site whose origin host is host
+func (s *Service) SiteByHost(host string) *Site {
+	for _, site := range s.Sites {
+		if strings.EqualFold(site.Origin.Host, host) {
+			return site
+		}
+	}
+	return nil
+}
+
+// TargetURL build the upstream url of a request path, path rewrites are applied
+func (site *Site) TargetURL(path string, rawQuery string) string {
+	for _, rewrite := range site.PathRewrites {
+		if rest, ok := strings.CutPrefix(path, rewrite.From); ok {
+			path = rewrite.To + rest
+			break
+		}
+	}
+	target := *site.Origin
+	target.Path = "/" + strings.TrimPrefix(path, "/")
+	target.RawPath = ""
+	target.RawQuery = rawQuery
+	return target.String()
+}
+
+// ApplyRequestHeaders override upstream request headers
+func (site *Site) ApplyRequestHeaders(header http.Header) {
+	overrideHeaders(header, site.RequestHeaders)
+}
+
+// ApplyResponseHeaders override client response headers
+func (site *Site) ApplyResponseHeaders(header http.Header) {
+	overrideHeaders(header, site.ResponseHeaders)
+}
+
+func overrideHeaders(header http.Header, overrides map[string]string) {
+	for key, value := range overrides {
+		key = textproto.CanonicalMIMEHeaderKey(key)
+		if value == "" {
+			header.Del(key)
+		} else {
+			header.Set(key, value)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package proxies
+
+import (
+	"game-mining-server/configs"
+	"testing"
+)
+
+func TestNewSitesPrefixes(t *testing.T) {
+	pancake := &configs.ProxySiteConfig{Name: "pancakeswap", Prefix: "/_next", Origin: "https://pancakeswap.finance"}
+	cases := []struct {
+		name  string
+		sites []*configs.ProxySiteConfig
+		valid bool
+	}{
+		{"default", nil, true},
+		{"shared prefix with different origins", []*configs.ProxySiteConfig{pancake, {Name: "other", Prefix: "_next/", Origin: "https://other.example"}}, true},
+		{"duplicate", []*configs.ProxySiteConfig{pancake, {Name: "copy", Prefix: "/_next", Origin: "https://PancakeSwap.finance/"}}, false},
+		{"nested", []*configs.ProxySiteConfig{pancake, {Name: "nested", Prefix: "/_next/static", Origin: "https://other.example"}}, false},
+		{"parent", []*configs.ProxySiteConfig{{Name: "nested", Prefix: "/_next/static", Origin: "https://other.example"}, pancake}, false},
+		{"similar", []*configs.ProxySiteConfig{pancake, {Name: "similar", Prefix: "/_nextjs", Origin: "https://other.example"}}, true},
+		{"api", []*configs.ProxySiteConfig{{Name: "api", Prefix: "/api", Origin: "https://other.example"}}, false},
+		{"under api", []*configs.ProxySiteConfig{{Name: "api", Prefix: "/api/1/static", Origin: "https://other.example"}}, false},
+		{"proxy", []*configs.ProxySiteConfig{{Name: "proxy", Prefix: "proxy", Origin: "https://other.example"}}, false},
+		{"wallet", []*configs.ProxySiteConfig{{Name: "wallet", Prefix: "/wallet/", Origin: "https://other.example"}}, false},
+		{"bot", []*configs.ProxySiteConfig{{Name: "bot", Prefix: "/bot", Origin: "https://other.example"}}, false},
+		{"empty", []*configs.ProxySiteConfig{{Name: "empty", Prefix: "/", Origin: "https://other.example"}}, false},
+	}
+	for _, c := range cases {
+		_, e := newSites(c.sites)
+		if c.valid && e != nil {
+			t.Errorf("%s: want valid, got %s", c.name, e)
+		} else if !c.valid && e == nil {
+			t.Errorf("%s: want error", c.name)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package proxies
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+var ErrNotUpgraded = errors.New("upstream did not switch to websocket")
+
+// IsWebSocketRequest whether client asks for a websocket upgrade
+func IsWebSocketRequest(req *http.Request) bool {
+	return headerHasToken(req.Header, "Connection", "upgrade") && strings.EqualFold(req.Header.Get("Upgrade"), "websocket")
+}
+
+// WebSocketHttpURL map ws and wss urls to http and https, which are checked by guard and dialed by transport
+func WebSocketHttpURL(u *url.URL) *url.URL {
+	target := *u
+	switch strings.ToLower(u.Scheme) {
+	case "ws":
+		target.Scheme = "http"
+	case "wss":
+		target.Scheme = "https"
+	}
+	return &target
+}
+
+// SetUpgradeHeaders restore hop-by-hop upgrade headers dropped by CopyHeaders
+func SetUpgradeHeaders(header http.Header) {
+	header.Set("Connection", "Upgrade")
+	header.Set("Upgrade", "websocket")
+}
+
+// Upgrade send a websocket handshake upstream, the returned connection carries raw frames after 101.
+// Transport only negotiates http/1.1 for upgrade requests, so it shares the guarded dialer with other requests.
+func (s *Service) Upgrade(req *http.Request) (*http.Response, io.ReadWriteCloser, error) {
+	res, e0 := s.Client.Do(req)
+	if e0 != nil {
+		return nil, nil, e0
+	}
+	if res.StatusCode != http.StatusSwitchingProtocols {
+		_ = res.Body.Close()
+		return res, nil, fmt.Errorf("%w: status %d", ErrNotUpgraded, res.StatusCode)
+	}
+	conn, ok := res.Body.(io.ReadWriteCloser)
+	if !ok {
+		_ = res.Body.Close()
+		return res, nil, ErrNotUpgraded
+	}
+	return res, conn, nil
+}
+
+// WriteSwitchingProtocols write upstream 101 response to the hijacked client connection
+func WriteSwitchingProtocols(w *bufio.ReadWriter, res *http.Response) error {
+	if _, e0 := w.WriteString("HTTP/1.1 101 Switching Protocols\r\n"); e0 != nil {
+		return e0
+	}
+	if e1 := res.Header.Write(w); e1 != nil {
+		return e1
+	}
+	if _, e2 := w.WriteString("\r\n"); e2 != nil {
+		return e2
+	}
+	return w.Flush()
+}
+
+// Pipe copy frames between client and upstream until either side closes or no data flows within idleTimeout
+func Pipe(client net.Conn, clientBuf *bufio.Reader, upstream io.ReadWriteCloser, idleTimeout time.Duration) {
+	var once sync.Once
+	closeBoth := func() {
+		once.Do(func() {
+			_ = client.Close()
+			_ = upstream.Close()
+		})
+	}
+	idle := time.AfterFunc(idleTimeout, closeBoth)
+	defer idle.Stop()
+
+	done := make(chan struct{}, 2)
+	copyFn := func(dst io.Writer, src io.Reader) {
+		_, _ = io.Copy(dst, &activityReader{r: src, onRead: func() { idle.Reset(idleTimeout) }})
+		closeBoth()
+		done <- struct{}{}
+	}
+	go copyFn(upstream, clientBuf)
+	go copyFn(client, upstream)
+	<-done
+	<-done
+}
+
+type activityReader struct {
+	r      io.Reader
+	onRead func()
+}
+
+func (a *activityReader) Read(p []byte) (int, error) {
+	n, err := a.r.Read(p)
+	if n > 0 {
+		a.onRead()
+	}
+	return n, err
+}
+
+// ConnLimiter limit concurrent connections per client ip
+type ConnLimiter struct {
+	mu     sync.Mutex
+	max    int
+	counts map[string]int
+}
+
+func NewConnLimiter(max int) *ConnLimiter {
+	return &ConnLimiter{max: max, counts: make(map[string]int)}
+}
+
+// Acquire take a connection slot of ip, return false if ip has reached the limit
+func (l *ConnLimiter) Acquire(ip string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.counts[ip] >= l.max {
+		return false
+	}
+	l.counts[ip]++
+	return true
+}
+
+// Release give back a connection slot of ip
+func (l *ConnLimiter) Release(ip string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.counts[ip] <= 1 {
+		delete(l.counts, ip)
+	} else {
+		l.counts[ip]--
+	}
+}
+
+func headerHasToken(header http.Header, key string, token string) bool {
+	for _, value := range header.Values(key) {
+		for _, field := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(field), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
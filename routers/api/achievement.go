@@ -0,0 +1,48 @@
+package api
+
+import (
+	"game-mining-server/app"
+	"game-mining-server/entities"
+	"game-mining-server/routers/middleware"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// GetAchievementBadges
+// @Tags Achievement
+// @Router /achievement/badges [get]
+// @Summary Get current user's badges
+// @description Get badges awarded to current user with awarded time and points, newest first
+func GetAchievementBadges(c *gin.Context) {
+	user := middleware.CurrentRequestUser(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, middleware.ResFailed(c, entities.ErrUserNotFound, "unauthorized"))
+		return
+	}
+	badges, e0 := app.DB().AchievementFindByUid(user.Id)
+	if e0 != nil {
+		c.JSON(http.StatusInternalServerError, middleware.ResFailedError(c, entities.ErrInternalDBQueryFailed, e0))
+	} else {
+		c.JSON(http.StatusOK, entities.ResSuccess(badges))
+	}
+}
+
+// GetAchievementProgress
+// @Tags Achievement
+// @Router /achievement/progress [get]
+// @Summary Get all achievements with current user's progress
+// @description Get all achievements with target, reward and current user's progress, awarded ones have awardedAt.
+// @description Progress of rank achievements is user's current rank on leaderboard, 0 if user has no points
+func GetAchievementProgress(c *gin.Context) {
+	user := middleware.CurrentRequestUser(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, middleware.ResFailed(c, entities.ErrUserNotFound, "unauthorized"))
+		return
+	}
+	progress, e0 := app.DB().AchievementFindProgress(user.Id, app.Config())
+	if e0 != nil {
+		c.JSON(http.StatusInternalServerError, middleware.ResFailedError(c, entities.ErrInternalDBQueryFailed, e0))
+	} else {
+		c.JSON(http.StatusOK, entities.ResSuccess(progress))
+	}
+}
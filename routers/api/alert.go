@@ -0,0 +1,189 @@
+package api
+
+import (
+	"errors"
+	"game-mining-server/app"
+	"game-mining-server/configs"
+	"game-mining-server/dbs"
+	"game-mining-server/entities"
+	"game-mining-server/routers/middleware"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"net/http"
+	"strings"
+)
+
+const maxPriceAlertsPerUser = 20
+
+// CreatePriceAlert
+// @Tags Alert
+// @Router /alert/create [post]
+// @Summary Create a price alert
+// @description Create a price alert, user is notified by bot when coin price rises above or falls below target.
+// @description One-shot alert stops after notified once, recurring alert notifies again after price crosses back.
+func CreatePriceAlert(c *gin.Context) {
+	user, params := middleware.CheckUserAndJsonParams[entities.CreatePriceAlertParam](c)
+	if user == nil || params == nil {
+		return
+	}
+
+	coinSymbol := strings.ToUpper(params.CoinSymbol)
+	fiatSymbol := strings.ToUpper(params.FiatSymbol)
+	if fiatSymbol == "" {
+		fiatSymbol = configs.FiatUSD
+	}
+	rate, ok := fiatRate(c, fiatSymbol)
+	if !ok {
+		return
+	}
+
+	count, e0 := app.DB().PriceAlertCountByUid(user.Id)
+	if e0 != nil {
+		c.JSON(http.StatusInternalServerError, middleware.ResFailedError(c, entities.ErrInternalDBQueryFailed, e0))
+		return
+	}
+	if count >= maxPriceAlertsPerUser {
+		c.JSON(http.StatusBadRequest, middleware.ResFailed(c, entities.ErrPriceAlertLimit, "too many price alerts"))
+		return
+	}
+
+	// only coins known by price providers can be alerted
+	coinPriceMap, e1 := app.Price().GetCachedUSDPrices(c, []string{coinSymbol})
+	if e1 != nil {
+		c.JSON(http.StatusBadGateway, middleware.ResFailedError(c, entities.ErrPriceUnavailable, e1))
+		return
+	}
+	usdPrice, ok := coinPriceMap[coinSymbol]
+	if !ok {
+		c.JSON(http.StatusBadRequest, middleware.ResFailed(c, entities.ErrPriceCoinNotSupported, coinSymbol))
+		return
+	}
+
+	alert := &dbs.PriceAlert{
+		Uid:         user.Id,
+		CoinSymbol:  coinSymbol,
+		FiatSymbol:  fiatSymbol,
+		Direction:   params.Direction,
+		TargetPrice: params.TargetPrice,
+		Recurring:   params.Recurring,
+	}
+	// an alert created past its target waits for price to cross back
+	alert.Arm(usdPrice * rate)
+	if e2 := app.DB().PriceAlertCreate(alert); e2 != nil {
+		c.JSON(http.StatusInternalServerError, middleware.ResFailedError(c, entities.ErrInternalDBInsertFailed, e2))
+	} else {
+		c.JSON(http.StatusOK, entities.ResSuccess(alert))
+	}
+}
+
+// GetPriceAlerts
+// @Tags Alert
+// @Router /alert/list [get]
+// @Summary Get current user's price alerts
+// @description Get current user's price alerts including triggered one-shot alerts, newest first
+func GetPriceAlerts(c *gin.Context) {
+	user := middleware.CurrentRequestUser(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, middleware.ResFailed(c, entities.ErrUserNotFound, "unauthorized"))
+		return
+	}
+	alerts, e0 := app.DB().PriceAlertFindByUid(user.Id)
+	if e0 != nil {
+		c.JSON(http.StatusInternalServerError, middleware.ResFailedError(c, entities.ErrInternalDBQueryFailed, e0))
+	} else {
+		c.JSON(http.StatusOK, entities.ResSuccess(alerts))
+	}
+}
+
+// UpdatePriceAlert
+// @Tags Alert
+// @Router /alert/{id}/update [post]
+// @Summary Update a price alert
+// @description Update direction, target or recurring of a price alert, only provided fields are updated.
+// @description The alert is active again after update, and armed if price has not reached its target yet.
+func UpdatePriceAlert(c *gin.Context) {
+	var idParams entities.PriceAlertIdParam
+	if e0 := c.ShouldBindUri(&idParams); e0 != nil {
+		c.JSON(http.StatusBadRequest, middleware.ResFailed(c, entities.ErrInvalidParams, e0.Error()))
+		return
+	}
+	user, params := middleware.CheckUserAndJsonParams[entities.UpdatePriceAlertParam](c)
+	if user == nil || params == nil {
+		return
+	}
+
+	alert, e1 := app.DB().PriceAlertFindById(user.Id, idParams.Id)
+	if errors.Is(e1, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusBadRequest, middleware.ResFailed(c, entities.ErrInvalidParams, "price alert not found"))
+		return
+	} else if e1 != nil {
+		c.JSON(http.StatusInternalServerError, middleware.ResFailedError(c, entities.ErrInternalDBQueryFailed, e1))
+		return
+	}
+	rate, ok := fiatRate(c, alert.FiatSymbol)
+	if !ok {
+		return
+	}
+	coinPriceMap, e2 := app.Price().GetCachedUSDPrices(c, []string{alert.CoinSymbol})
+	if e2 != nil {
+		c.JSON(http.StatusBadGateway, middleware.ResFailedError(c, entities.ErrPriceUnavailable, e2))
+		return
+	}
+	usdPrice, ok := coinPriceMap[alert.CoinSymbol]
+	if !ok {
+		c.JSON(http.StatusServiceUnavailable, middleware.ResFailed(c, entities.ErrPriceUnavailable, alert.CoinSymbol))
+		return
+	}
+
+	updated := make(map[string]interface{})
+	if params.Direction != nil {
+		alert.Direction = *params.Direction
+		updated["direction"] = alert.Direction
+	}
+	if params.TargetPrice != nil {
+		alert.TargetPrice = *params.TargetPrice
+		updated["target_price"] = alert.TargetPrice
+	}
+	if params.Recurring != nil {
+		updated["recurring"] = *params.Recurring
+	}
+	// the updated alert is armed only if price has not reached its new target yet
+	alert.Arm(usdPrice * rate)
+	updated["armed"] = alert.Armed
+
+	updatedAlert, e3 := app.DB().PriceAlertUpdate(user.Id, idParams.Id, updated)
+	if errors.Is(e3, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusBadRequest, middleware.ResFailed(c, entities.ErrInvalidParams, "price alert not found"))
+	} else if e3 != nil {
+		c.JSON(http.StatusInternalServerError, middleware.ResFailedError(c, entities.ErrInternalDBUpdateFailed, e3))
+	} else {
+		c.JSON(http.StatusOK, entities.ResSuccess(updatedAlert))
+	}
+}
+
+// DeletePriceAlert
+// @Tags Alert
+// @Router /alert/{id} [delete]
+// @Summary Delete a price alert
+// @description Delete a price alert of current user
+func DeletePriceAlert(c *gin.Context) {
+	var params entities.PriceAlertIdParam
+	if e0 := c.ShouldBindUri(&params); e0 != nil {
+		c.JSON(http.StatusBadRequest, middleware.ResFailed(c, entities.ErrInvalidParams, e0.Error()))
+		return
+	}
+	user := middleware.CurrentRequestUser(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, middleware.ResFailed(c, entities.ErrUserNotFound, "unauthorized"))
+		return
+	}
+
+	deleted, e1 := app.DB().PriceAlertDelete(user.Id, params.Id)
+	if e1 != nil {
+		c.JSON(http.StatusInternalServerError, middleware.ResFailedError(c, entities.ErrInternalDBDeleteFailed, e1))
+	} else if !deleted {
+		c.JSON(http.StatusBadRequest, middleware.ResFailed(c, entities.ErrInvalidParams, "price alert not found"))
+	} else {
+		c.JSON(http.StatusOK, entities.ResSuccess(params.Id))
+	}
+}
@@ -0,0 +1,71 @@
+package api
+
+import (
+	"errors"
+	"game-mining-server/app"
+	"game-mining-server/configs"
+	"game-mining-server/dbs"
+	"game-mining-server/entities"
+	"game-mining-server/routers/middleware"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"time"
+)
+
+type BoostersRes struct {
+	Shop   []*configs.BoosterConfig `json:"shop"`
+	Active []*dbs.ActiveBooster     `json:"active"`
+}
+
+type BuyBoosterRes struct {
+	Booster *dbs.Booster `json:"booster"`
+	Point   *dbs.Point   `json:"point"`
+}
+
+// GetBoosters
+// @Tags Booster
+// @Router /booster/list [get]
+// @Summary Get boosters in shop and current user's active boosters
+// @description Get boosters in shop with their cost, boosters with 0 cost are only granted by tasks.
+// @description Active boosters are listed with their remaining seconds
+func GetBoosters(c *gin.Context) {
+	user := middleware.CurrentRequestUser(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, middleware.ResFailed(c, entities.ErrUserNotFound, "unauthorized"))
+		return
+	}
+
+	active, e0 := app.DB().BoosterFindActive(app.DB().DBInstance, user.Id, time.Now().UnixMilli())
+	if e0 != nil {
+		c.JSON(http.StatusInternalServerError, middleware.ResFailedError(c, entities.ErrInternalDBQueryFailed, e0))
+		return
+	}
+	c.JSON(http.StatusOK, entities.ResSuccess(&BoostersRes{Shop: dbs.Boosters(app.Config()), Active: active}))
+}
+
+// BuyBooster
+// @Tags Booster
+// @Router /booster/buy [post]
+// @Summary Current user buy a booster with points
+// @description Buy a booster with points and activate it. A multiplier booster multiplies points claimed by tasks,
+// @description wallet and invites while active, buying it again extends its duration. Energy refill fills energy at once
+func BuyBooster(c *gin.Context) {
+	user, params := middleware.CheckUserAndJsonParams[entities.BuyBoosterParam](c)
+	if user == nil || params == nil {
+		return
+	}
+
+	booster, point, e0 := app.DB().BoosterBuy(user.Id, params.BoosterId, app.Config())
+	switch {
+	case errors.Is(e0, dbs.ErrBoosterNotFound):
+		c.JSON(http.StatusBadRequest, middleware.ResFailed(c, entities.ErrInvalidParams, e0.Error()))
+	case errors.Is(e0, dbs.ErrBoosterNotForSale):
+		c.JSON(http.StatusBadRequest, middleware.ResFailed(c, entities.ErrBoosterNotForSale, e0.Error()))
+	case errors.Is(e0, dbs.ErrNotEnoughPoints):
+		c.JSON(http.StatusBadRequest, middleware.ResFailed(c, entities.ErrNotEnoughPoints, e0.Error()))
+	case e0 != nil:
+		c.JSON(http.StatusInternalServerError, middleware.ResFailedError(c, entities.ErrInternalDBUpdateFailed, e0))
+	default:
+		c.JSON(http.StatusOK, entities.ResSuccess(&BuyBoosterRes{Booster: booster, Point: point}))
+	}
+}
@@ -0,0 +1,41 @@
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+	"game-mining-server/app"
+	"game-mining-server/entities"
+	"game-mining-server/handlers"
+	"game-mining-server/routers/middleware"
+	"github.com/gin-gonic/gin"
+	"io"
+	"net/http"
+)
+
+// BotWebhook Receive telegram bot updates in webhook mode, request must carry the configured secret token
+func BotWebhook(c *gin.Context) {
+	handler := handlers.WebhookHandler()
+	if handler == nil {
+		c.JSON(http.StatusNotFound, middleware.ResFailed(c, entities.ErrBotWebhookDisabled, "bot webhook disabled"))
+		return
+	}
+
+	secret := c.GetHeader("X-Telegram-Bot-Api-Secret-Token")
+	if subtle.ConstantTimeCompare([]byte(secret), []byte(app.Config().Bot.WebhookSecret)) != 1 {
+		c.JSON(http.StatusUnauthorized, middleware.ResFailed(c, entities.ErrBotWebhookInvalidSecret, "invalid secret token"))
+		return
+	}
+
+	body, e0 := io.ReadAll(c.Request.Body)
+	if e0 != nil {
+		c.JSON(http.StatusBadRequest, middleware.ResFailed(c, entities.ErrInvalidParams, e0.Error()))
+		return
+	}
+
+	// update is processed after request finished, so the context should not be canceled with request
+	if e1 := handler(context.WithoutCancel(c.Request.Context()), body); e1 != nil {
+		c.JSON(http.StatusInternalServerError, middleware.ResFailedError(c, entities.ErrBotWebhookHandleFailed, e1))
+		return
+	}
+	c.Status(http.StatusOK)
+}
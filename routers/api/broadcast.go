@@ -0,0 +1,98 @@
+package api
+
+import (
+	"errors"
+	"game-mining-server/app"
+	"game-mining-server/configs"
+	"game-mining-server/dbs"
+	"game-mining-server/entities"
+	"game-mining-server/routers/middleware"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// CreateBroadcast
+// @Tags Admin
+// @Router /admin/broadcast/create [post]
+// @Summary Admin create a broadcast to all users
+// @description Admin create a broadcast with text, optional photo and button, it is delivered in background
+func CreateBroadcast(c *gin.Context) {
+	user, params := middleware.CheckUserAndJsonParams[entities.CreateBroadcastParam](c)
+	if user == nil || params == nil {
+		return
+	}
+	broadcast := &dbs.Broadcast{
+		CreatedBy:  user.Id,
+		Text:       params.Text,
+		Photo:      params.Photo,
+		ButtonText: params.ButtonText,
+		ButtonUrl:  params.ButtonUrl,
+	}
+	if e0 := app.DB().BroadcastCreate(broadcast); errors.Is(e0, dbs.ErrBroadcastCaptionTooLong) {
+		c.JSON(http.StatusBadRequest, middleware.ResFailed(c, entities.ErrInvalidParams, e0.Error()))
+	} else if e0 != nil {
+		c.JSON(http.StatusInternalServerError, middleware.ResFailedError(c, entities.ErrInternalDBInsertFailed, e0))
+	} else {
+		c.JSON(http.StatusOK, entities.ResSuccess(broadcast))
+	}
+}
+
+// GetBroadcast
+// @Tags Admin
+// @Router /admin/broadcast/{id} [get]
+// @Summary Admin get a broadcast and its delivery progress
+// @description Admin get a broadcast and its delivery progress
+func GetBroadcast(c *gin.Context) {
+	var params entities.BroadcastIdParam
+	if e0 := c.ShouldBindUri(&params); e0 != nil {
+		c.JSON(http.StatusBadRequest, middleware.ResFailed(c, entities.ErrInvalidParams, e0.Error()))
+		return
+	}
+	broadcast, e1 := app.DB().BroadcastFindById(params.Id)
+	if e1 != nil {
+		c.JSON(http.StatusBadRequest, middleware.ResFailedError(c, entities.ErrInternalDBQueryFailed, e1))
+	} else {
+		c.JSON(http.StatusOK, entities.ResSuccess(broadcast))
+	}
+}
+
+// PauseBroadcast
+// @Tags Admin
+// @Router /admin/broadcast/{id}/pause [post]
+// @Summary Admin pause a running broadcast
+// @description Admin pause a running broadcast
+func PauseBroadcast(c *gin.Context) {
+	updateBroadcastStatus(c, configs.BroadcastStatusPaused)
+}
+
+// ResumeBroadcast
+// @Tags Admin
+// @Router /admin/broadcast/{id}/resume [post]
+// @Summary Admin resume a paused broadcast
+// @description Admin resume a paused broadcast
+func ResumeBroadcast(c *gin.Context) {
+	updateBroadcastStatus(c, configs.BroadcastStatusRunning)
+}
+
+// CancelBroadcast
+// @Tags Admin
+// @Router /admin/broadcast/{id}/cancel [post]
+// @Summary Admin cancel a running or paused broadcast
+// @description Admin cancel a running or paused broadcast, users not delivered yet will not receive it
+func CancelBroadcast(c *gin.Context) {
+	updateBroadcastStatus(c, configs.BroadcastStatusCancelled)
+}
+
+func updateBroadcastStatus(c *gin.Context, status int) {
+	var params entities.BroadcastIdParam
+	if e0 := c.ShouldBindUri(&params); e0 != nil {
+		c.JSON(http.StatusBadRequest, middleware.ResFailed(c, entities.ErrInvalidParams, e0.Error()))
+		return
+	}
+	broadcast, e1 := app.DB().BroadcastUpdateStatus(params.Id, status)
+	if e1 != nil {
+		c.JSON(http.StatusBadRequest, middleware.ResFailedError(c, entities.ErrInternalDBUpdateFailed, e1))
+	} else {
+		c.JSON(http.StatusOK, entities.ResSuccess(broadcast))
+	}
+}
@@ -0,0 +1,101 @@
+package api
+
+import (
+	"errors"
+	"game-mining-server/achievements"
+	"game-mining-server/app"
+	"game-mining-server/configs"
+	"game-mining-server/dbs"
+	"game-mining-server/entities"
+	"game-mining-server/routers/middleware"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"time"
+)
+
+type MiningClaimRes struct {
+	Mining *dbs.MiningStatus `json:"mining"`
+	Point  *dbs.Point        `json:"point"`
+}
+
+// MiningClaim
+// @Tags Mining
+// @Router /mining/claim [post]
+// @Summary Current user claim mined points
+// @description Credit points mined since last claim to current user's point, mining stops when storage is full until claimed
+func MiningClaim(c *gin.Context) {
+	user := middleware.CurrentRequestUser(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, middleware.ResFailed(c, entities.ErrUserNotFound, "unauthorized"))
+		return
+	}
+
+	mining, point, e0 := app.DB().MiningClaim(user.Id, user.Perks(app.Config().Premium).ExtraMiningCapacity, app.Config().Mining)
+	if errors.Is(e0, dbs.ErrMiningNothingToClaim) {
+		c.JSON(http.StatusBadRequest, middleware.ResFailed(c, entities.ErrMiningNothingToClaim, e0.Error()))
+	} else if e0 != nil {
+		c.JSON(http.StatusInternalServerError, middleware.ResFailedError(c, entities.ErrInternalDBUpdateFailed, e0))
+	} else {
+		achievements.Emit(user.Id, configs.AchievementEventPoints)
+		c.JSON(http.StatusOK, entities.ResSuccess(&MiningClaimRes{Mining: mining, Point: point}))
+	}
+}
+
+type MiningUpgradesRes struct {
+	Mining   *dbs.MiningStatus    `json:"mining"`
+	Upgrades []*dbs.MiningUpgrade `json:"upgrades"`
+	Point    *dbs.Point           `json:"point,omitempty"`
+}
+
+// GetMiningUpgrades
+// @Tags Mining
+// @Router /mining/upgrades [get]
+// @Summary Get mining upgrades in shop with current user's levels
+// @description Get rate, capacity and auto claim upgrades with current level, value and cost of next level
+func GetMiningUpgrades(c *gin.Context) {
+	user := middleware.CurrentRequestUser(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, middleware.ResFailed(c, entities.ErrUserNotFound, "unauthorized"))
+		return
+	}
+
+	mining, e0 := app.DB().MiningFindOrCreate(user.Id, user.Perks(app.Config().Premium).ExtraMiningCapacity, app.Config().Mining)
+	if e0 != nil {
+		c.JSON(http.StatusInternalServerError, middleware.ResFailedError(c, entities.ErrInternalDBQueryFailed, e0))
+		return
+	}
+	c.JSON(http.StatusOK, entities.ResSuccess(&MiningUpgradesRes{
+		Mining:   mining.Status(time.Now().UnixMilli()),
+		Upgrades: mining.Upgrades(app.Config().Mining),
+	}))
+}
+
+// MiningBuyUpgrade
+// @Tags Mining
+// @Router /mining/upgrade [post]
+// @Summary Current user buy next level of a mining upgrade
+// @description Buy next level of rate, capacity or auto claim upgrade, mined points are claimed before the cost is paid
+func MiningBuyUpgrade(c *gin.Context) {
+	user, params := middleware.CheckUserAndJsonParams[entities.MiningUpgradeParam](c)
+	if user == nil || params == nil {
+		return
+	}
+
+	mining, point, e0 := app.DB().MiningBuyUpgrade(user.Id, params.Type, user.Perks(app.Config().Premium).ExtraMiningCapacity, app.Config().Mining)
+	switch {
+	case errors.Is(e0, dbs.ErrNotEnoughPoints):
+		c.JSON(http.StatusBadRequest, middleware.ResFailed(c, entities.ErrNotEnoughPoints, e0.Error()))
+	case errors.Is(e0, dbs.ErrUpgradeMaxLevel):
+		c.JSON(http.StatusBadRequest, middleware.ResFailed(c, entities.ErrUpgradeMaxLevel, e0.Error()))
+	case errors.Is(e0, dbs.ErrUpgradeNotFound):
+		c.JSON(http.StatusBadRequest, middleware.ResFailed(c, entities.ErrInvalidParams, e0.Error()))
+	case e0 != nil:
+		c.JSON(http.StatusInternalServerError, middleware.ResFailedError(c, entities.ErrInternalDBUpdateFailed, e0))
+	default:
+		c.JSON(http.StatusOK, entities.ResSuccess(&MiningUpgradesRes{
+			Mining:   mining,
+			Upgrades: mining.Upgrades(app.Config().Mining),
+			Point:    point,
+		}))
+	}
+}
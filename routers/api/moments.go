@@ -2,8 +2,12 @@ package api
 
 import (
 	"fmt"
+	"game-mining-server/achievements"
 	"game-mining-server/app"
+	"game-mining-server/configs"
 	"game-mining-server/entities"
+	"game-mining-server/notifications"
+	"game-mining-server/routers/middleware"
 	"github.com/gin-gonic/gin"
 	"net/http"
 )
@@ -11,16 +15,24 @@ import (
 func CreateMoment(c *gin.Context) {
 	var params entities.CreateMomentParam
 	if e0 := c.ShouldBindJSON(&params); e0 != nil {
-		c.JSON(http.StatusBadRequest, entities.ResFailed(entities.ErrInvalidParams, e0.Error()))
+		c.JSON(http.StatusBadRequest, middleware.ResFailed(c, entities.ErrInvalidParams, e0.Error()))
+		return
+	}
+
+	// moment is owned by the session user, so the moment counts for the user's own achievements
+	user := middleware.CurrentRequestUser(c)
+	if params.UserId != user.Id {
+		c.JSON(http.StatusForbidden, middleware.ResFailed(c, entities.ErrInvalidParams, "user_id must be current user"))
 		return
 	}
 
 	momentId, err := app.DB().CreateMoment(params)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, entities.ResFailed(entities.ErrInternalDBInsertFailed, fmt.Sprintf("Failed to create moment, %s", err.Error())))
+		c.JSON(http.StatusInternalServerError, middleware.ResFailedError(c, entities.ErrInternalDBInsertFailed, fmt.Errorf("failed to create moment: %w", err)))
 		return
 	}
 
+	achievements.Emit(user.Id, configs.AchievementEventMoment)
 	c.JSON(http.StatusOK, entities.ResSuccess(gin.H{
 		"message":   "Moment created successfully",
 		"moment_id": momentId,
@@ -30,13 +42,13 @@ func CreateMoment(c *gin.Context) {
 func DeleteMoment(c *gin.Context) {
 	var params entities.DeleteMomentParam
 	if e0 := c.ShouldBindJSON(&params); e0 != nil {
-		c.JSON(http.StatusBadRequest, entities.ResFailed(entities.ErrInvalidParams, e0.Error()))
+		c.JSON(http.StatusBadRequest, middleware.ResFailed(c, entities.ErrInvalidParams, e0.Error()))
 		return
 	}
 
 	err := app.DB().DeleteMoment(params)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, entities.ResFailed(entities.ErrInternalDBInsertFailed, fmt.Sprintf("Failed to delete moment, %s", err.Error())))
+		c.JSON(http.StatusInternalServerError, middleware.ResFailedError(c, entities.ErrInternalDBInsertFailed, fmt.Errorf("failed to delete moment: %w", err)))
 		return
 	}
 
@@ -46,13 +58,13 @@ func DeleteMoment(c *gin.Context) {
 func GetLatestMoments(c *gin.Context) {
 	var params entities.GetLatestMomentsParam
 	if err := c.ShouldBindQuery(&params); err != nil {
-		c.JSON(http.StatusBadRequest, entities.ResFailed(entities.ErrInvalidParams, err.Error()))
+		c.JSON(http.StatusBadRequest, middleware.ResFailed(c, entities.ErrInvalidParams, err.Error()))
 		return
 	}
 
 	moments, err := app.DB().GetLatestMoments(params)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, entities.ResFailed(entities.ErrInternalDBInsertFailed, fmt.Sprintf("Failed to get moments, %s", err.Error())))
+		c.JSON(http.StatusInternalServerError, middleware.ResFailedError(c, entities.ErrInternalDBInsertFailed, fmt.Errorf("failed to get moments: %w", err)))
 		return
 	}
 
@@ -62,16 +74,18 @@ func GetLatestMoments(c *gin.Context) {
 func AddComment(c *gin.Context) {
 	var params entities.AddCommentParam
 	if e0 := c.ShouldBindJSON(&params); e0 != nil {
-		c.JSON(http.StatusBadRequest, entities.ResFailed(entities.ErrInvalidParams, e0.Error()))
+		c.JSON(http.StatusBadRequest, middleware.ResFailed(c, entities.ErrInvalidParams, e0.Error()))
 		return
 	}
 
 	commentId, err := app.DB().CommentMoment(params)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, entities.ResFailed(entities.ErrInternalDBInsertFailed, fmt.Sprintf("Failed to add comment, %s", err.Error())))
+		c.JSON(http.StatusInternalServerError, middleware.ResFailedError(c, entities.ErrInternalDBInsertFailed, fmt.Errorf("failed to add comment: %w", err)))
 		return
 	}
 
+	// actor is the session user, user id in body is not trusted
+	notifications.NotifyMomentCommented(params.MomentId, middleware.CurrentRequestUser(c).Id, params.Content)
 	c.JSON(http.StatusOK, entities.ResSuccess(gin.H{
 		"message":    "comment added successfully",
 		"comment_id": commentId,
@@ -81,13 +95,13 @@ func AddComment(c *gin.Context) {
 func DeleteComment(c *gin.Context) {
 	var params entities.DeleteCommentParam
 	if e0 := c.ShouldBindJSON(&params); e0 != nil {
-		c.JSON(http.StatusBadRequest, entities.ResFailed(entities.ErrInvalidParams, e0.Error()))
+		c.JSON(http.StatusBadRequest, middleware.ResFailed(c, entities.ErrInvalidParams, e0.Error()))
 		return
 	}
 
 	err := app.DB().DeleteComment(params)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, entities.ResFailed(entities.ErrInternalDBInsertFailed, fmt.Sprintf("Failed to delete comment, %s", err.Error())))
+		c.JSON(http.StatusInternalServerError, middleware.ResFailedError(c, entities.ErrInternalDBInsertFailed, fmt.Errorf("failed to delete comment: %w", err)))
 		return
 	}
 
@@ -97,13 +111,13 @@ func DeleteComment(c *gin.Context) {
 func GetCommentsForMoment(c *gin.Context) {
 	var params entities.GetCommentsForMomentParam
 	if e0 := c.ShouldBindQuery(&params); e0 != nil {
-		c.JSON(http.StatusBadRequest, entities.ResFailed(entities.ErrInvalidParams, e0.Error()))
+		c.JSON(http.StatusBadRequest, middleware.ResFailed(c, entities.ErrInvalidParams, e0.Error()))
 		return
 	}
 
 	comments, err := app.DB().GetCommentsForMoment(params)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, entities.ResFailed(entities.ErrInternalDBInsertFailed, "Failed to get comments"))
+		c.JSON(http.StatusInternalServerError, middleware.ResFailed(c, entities.ErrInternalDBInsertFailed, "Failed to get comments"))
 		return
 	}
 
@@ -113,29 +127,30 @@ func GetCommentsForMoment(c *gin.Context) {
 func LikeMoment(c *gin.Context) {
 	var params entities.LikeMomentParam
 	if e0 := c.ShouldBindJSON(&params); e0 != nil {
-		c.JSON(http.StatusBadRequest, entities.ResFailed(entities.ErrInvalidParams, e0.Error()))
+		c.JSON(http.StatusBadRequest, middleware.ResFailed(c, entities.ErrInvalidParams, e0.Error()))
 		return
 	}
 
 	err := app.DB().LikeMoment(params)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, entities.ResFailed(entities.ErrInternalDBInsertFailed, fmt.Sprintf("Failed to like moment, %s", err.Error())))
+		c.JSON(http.StatusInternalServerError, middleware.ResFailedError(c, entities.ErrInternalDBInsertFailed, fmt.Errorf("failed to like moment: %w", err)))
 		return
 	}
 
+	notifications.NotifyMomentLiked(params.MomentId, middleware.CurrentRequestUser(c).Id)
 	c.JSON(http.StatusOK, entities.ResSuccess("Moment liked successfully"))
 }
 
 func RollbackLikeMoment(c *gin.Context) {
 	var params entities.LikeMomentParam
 	if e0 := c.ShouldBindJSON(&params); e0 != nil {
-		c.JSON(http.StatusBadRequest, entities.ResFailed(entities.ErrInvalidParams, e0.Error()))
+		c.JSON(http.StatusBadRequest, middleware.ResFailed(c, entities.ErrInvalidParams, e0.Error()))
 		return
 	}
 
 	err := app.DB().RollbackLikeMoment(params)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, entities.ResFailed(entities.ErrInternalDBInsertFailed, fmt.Sprintf("Failed to rollback like moment, %s", err.Error())))
+		c.JSON(http.StatusInternalServerError, middleware.ResFailedError(c, entities.ErrInternalDBInsertFailed, fmt.Errorf("failed to rollback like moment: %w", err)))
 		return
 	}
 
@@ -145,15 +160,16 @@ func RollbackLikeMoment(c *gin.Context) {
 func RewardMoment(c *gin.Context) {
 	var params entities.RewardMomentParam
 	if e0 := c.ShouldBindJSON(&params); e0 != nil {
-		c.JSON(http.StatusBadRequest, entities.ResFailed(entities.ErrInvalidParams, e0.Error()))
+		c.JSON(http.StatusBadRequest, middleware.ResFailed(c, entities.ErrInvalidParams, e0.Error()))
 		return
 	}
 
 	err := app.DB().RewardMoment(params)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, entities.ResFailed(entities.ErrInternalDBInsertFailed, fmt.Sprintf("Failed to reward moment, %s", err.Error())))
+		c.JSON(http.StatusInternalServerError, middleware.ResFailedError(c, entities.ErrInternalDBInsertFailed, fmt.Errorf("failed to reward moment: %w", err)))
 		return
 	}
 
+	notifications.NotifyMomentRewarded(params.MomentId, middleware.CurrentRequestUser(c).Id, params.Amount)
 	c.JSON(http.StatusOK, entities.ResSuccess("Moment rewarded successfully"))
 }
@@ -0,0 +1,61 @@
+package api
+
+import (
+	"game-mining-server/app"
+	"game-mining-server/entities"
+	"game-mining-server/routers/middleware"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// GetNotificationPref
+// @Tags User
+// @Router /user/notification [get]
+// @Summary Get current user's notification preferences
+// @description Get current user's notification preferences, all notifications are enabled by default
+func GetNotificationPref(c *gin.Context) {
+	user := middleware.CurrentRequestUser(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, middleware.ResFailed(c, entities.ErrUserNotFound, "unauthorized"))
+		return
+	}
+	pref, e0 := app.DB().NotificationPrefFindByUid(user.Id)
+	if e0 != nil {
+		c.JSON(http.StatusInternalServerError, middleware.ResFailedError(c, entities.ErrInternalDBQueryFailed, e0))
+	} else {
+		c.JSON(http.StatusOK, entities.ResSuccess(pref))
+	}
+}
+
+// UpdateNotificationPref
+// @Tags User
+// @Router /user/notification [post]
+// @Summary Update current user's notification preferences
+// @description Update current user's notification preferences, only provided fields are updated
+func UpdateNotificationPref(c *gin.Context) {
+	user, params := middleware.CheckUserAndJsonParams[entities.NotificationPrefParam](c)
+	if user == nil || params == nil {
+		return
+	}
+
+	updated := make(map[string]interface{})
+	if params.MomentLike != nil {
+		updated["moment_like"] = *params.MomentLike
+	}
+	if params.MomentComment != nil {
+		updated["moment_comment"] = *params.MomentComment
+	}
+	if params.MomentReward != nil {
+		updated["moment_reward"] = *params.MomentReward
+	}
+	if params.CheckinReminder != nil {
+		updated["checkin_reminder"] = *params.CheckinReminder
+	}
+
+	pref, e0 := app.DB().NotificationPrefUpdate(user.Id, updated)
+	if e0 != nil {
+		c.JSON(http.StatusInternalServerError, middleware.ResFailedError(c, entities.ErrInternalDBUpdateFailed, e0))
+	} else {
+		c.JSON(http.StatusOK, entities.ResSuccess(pref))
+	}
+}
@@ -1,180 +1,413 @@
 package api
 
 import (
+	"bytes"
 	"compress/flate"
 	"compress/gzip"
+	"errors"
+	"fmt"
+	"game-mining-server/app"
+	"game-mining-server/caches"
 	"game-mining-server/entities"
+	"game-mining-server/proxies"
+	"game-mining-server/routers/middleware"
 	"github.com/andybalholm/brotli"
 	"github.com/gin-gonic/gin"
 	"io"
 	"log"
+	"mime"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
+	"time"
 )
 
-var htmlIgnoreHeaderKeys = []string{"x-frame-options", "content-encoding", "cross-origin-opener-policy", "referrer-policy"}
-var reqIgnoreHeaderKeys = []string{"x-frame-options", "cross-origin-opener-policy", "referrer-policy"}
-var relativePathReplacements = []string{"/_next", "/manifest.json", "/favicon.ico"}
+var htmlIgnoreHeaderKeys = []string{"content-encoding", "content-length"}
 
-func isIgnoreHeader(key string, ignoreKeys []string) bool {
-	for _, _key := range ignoreKeys {
-		if strings.ToLower(key) == _key {
-			return true
-		}
-	}
-	return false
-}
+// proxyCacheHeader tell client how a site resource is served: HIT, REVALIDATED, MISS or BYPASS
+const proxyCacheHeader = "X-Proxy-Cache"
+
+// htmlAcceptEncoding encodings of html pages which can be decoded for rewriting
+const htmlAcceptEncoding = "gzip, deflate, br"
 
+// switchContentEncoding decode response body, unknown and stacked encodings are rejected
 func switchContentEncoding(res *http.Response) (bodyReader io.Reader, err error) {
-	switch res.Header.Get("Content-Encoding") {
+	encoding := strings.ToLower(strings.TrimSpace(res.Header.Get("Content-Encoding")))
+	switch encoding {
 	case "br":
 		bodyReader = brotli.NewReader(res.Body)
-	case "gzip":
+	case "gzip", "x-gzip":
 		bodyReader, err = gzip.NewReader(res.Body)
 	case "deflate":
 		bodyReader = flate.NewReader(res.Body)
-	default:
+	case "", "identity":
 		bodyReader = res.Body
+	default:
+		err = fmt.Errorf("unsupported content encoding: %s", encoding)
 	}
 	return
 }
 
-// replace relative path to absolute path in html file
-func replaceRelativePathInRes(realUrl string, res *http.Response) (string, error) {
+// rewriteHtmlRes stream html response to client with links rewritten to the upstream site
+func rewriteHtmlRes(c *gin.Context, res *http.Response) {
 	resBody, e0 := switchContentEncoding(res)
 	if e0 != nil {
-		return "", e0
+		c.JSON(http.StatusBadGateway, middleware.ResFailedError(c, entities.ErrProxyParseResBodyFailed, e0))
+		return
 	}
 
-	body, e1 := io.ReadAll(resBody)
-	if e1 != nil {
-		return "", e1
+	// body is decoded and rewritten, so length and encoding of upstream no longer apply
+	proxies.CopyHeaders(c.Writer.Header(), res.Header, htmlIgnoreHeaderKeys...)
+	c.Status(res.StatusCode)
+
+	rewriter := app.Proxy().NewHtmlRewriter(res.Request.URL)
+	if e1 := rewriter.Rewrite(c.Writer, app.Proxy().LimitReader(resBody)); e1 != nil {
+		log.Printf("Proxy rewrite html %s failed: %s", res.Request.URL, e1)
 	}
-	realPath, e2 := url.Parse(realUrl)
-	if e2 != nil {
-		return "", e2
+}
+
+func isHtmlResponse(res *http.Response) bool {
+	mediaType, _, _ := mime.ParseMediaType(res.Header.Get("Content-Type"))
+	return mediaType == "text/html"
+}
+
+// newProxyRequest create an upstream request carrying client method, body and headers
+func newProxyRequest(c *gin.Context, targetUrl string) (*http.Request, error) {
+	body := http.MaxBytesReader(c.Writer, c.Request.Body, app.Proxy().MaxRequestBodyBytes)
+	req, e0 := http.NewRequestWithContext(c.Request.Context(), c.Request.Method, targetUrl, body)
+	if e0 != nil {
+		c.JSON(http.StatusBadRequest, middleware.ResFailedError(c, entities.ErrProxyCreateRequestFailed, e0))
+		return nil, e0
 	}
+	req.ContentLength = c.Request.ContentLength
+	proxies.CopyHeaders(req.Header, c.Request.Header)
+	return req, nil
+}
+
+// newGuardedRequest create a proxy request to a user provided url, response an error if url is not allowed
+func newGuardedRequest(c *gin.Context, rawUrl string) (*http.Request, error) {
+	targetUrl, e0 := url.Parse(rawUrl)
+	if e0 != nil {
+		c.JSON(http.StatusBadRequest, middleware.ResFailed(c, entities.ErrInvalidParams, e0.Error()))
+		return nil, e0
+	}
+	if e1 := app.Proxy().Guard.CheckURL(targetUrl); e1 != nil {
+		c.JSON(http.StatusForbidden, middleware.ResFailed(c, entities.ErrProxyUrlNotAllowed, e1.Error()))
+		return nil, e1
+	}
+	return newProxyRequest(c, targetUrl.String())
+}
 
-	htmlBody := string(body)
-	realPathPrefix := realPath.Scheme + "://" + realPath.Host // https://pancakeswap.com
-	for _, relativePath := range relativePathReplacements {
-		htmlBody = strings.ReplaceAll(htmlBody, "\""+relativePath, "\""+realPathPrefix+relativePath)
+// abortProxyRequestFailed response proxy request error, blocked redirects and addresses are forbidden
+func abortProxyRequestFailed(c *gin.Context, err error) {
+	var maxBytesErr *http.MaxBytesError
+	if proxies.IsForbidden(err) {
+		c.JSON(http.StatusForbidden, middleware.ResFailed(c, entities.ErrProxyUrlNotAllowed, err.Error()))
+	} else if errors.As(err, &maxBytesErr) {
+		c.JSON(http.StatusRequestEntityTooLarge, middleware.ResFailed(c, entities.ErrProxyBodyTooLarge, err.Error()))
+	} else {
+		c.JSON(http.StatusBadGateway, middleware.ResFailedError(c, entities.ErrProxyRequestFailed, err))
+	}
+}
+
+// streamProxyResponse pass upstream response through to client without buffering
+func streamProxyResponse(c *gin.Context, res *http.Response) {
+	maxBytes := app.Proxy().MaxResponseBodyBytes
+	if res.ContentLength > maxBytes {
+		c.JSON(http.StatusBadGateway, middleware.ResFailed(c, entities.ErrProxyBodyTooLarge, proxies.ErrResponseTooLarge.Error()))
+		return
+	}
+
+	proxies.CopyHeaders(c.Writer.Header(), res.Header)
+	c.Status(res.StatusCode)
+	written, err := io.Copy(c.Writer, io.LimitReader(res.Body, maxBytes))
+	if err == nil && written == maxBytes {
+		// body without content length may exceed the limit, stop here and the client sees a truncated response
+		if n, _ := res.Body.Read(make([]byte, 1)); n > 0 {
+			err = proxies.ErrResponseTooLarge
+		}
+	}
+	if err != nil {
+		log.Printf("Proxy stream %s failed after %d bytes: %s", res.Request.URL, written, err)
 	}
-	return htmlBody, nil
 }
 
 // ProxyGetHtml Request resource(html, css, js, images, assets) by proxy
 func ProxyGetHtml(c *gin.Context) {
 	var params entities.ProxyGetParam
 	if e0 := c.ShouldBindQuery(&params); e0 != nil {
-		c.JSON(http.StatusBadRequest, entities.ResFailed(entities.ErrInvalidParams, e0.Error()))
+		c.JSON(http.StatusBadRequest, middleware.ResFailed(c, entities.ErrInvalidParams, e0.Error()))
 		return
 	}
 
-	proxyReq, e1 := http.NewRequest(c.Request.Method, params.Url, c.Request.Body)
+	proxyReq, e1 := newGuardedRequest(c, params.Url)
 	if e1 != nil {
-		c.JSON(http.StatusBadRequest, entities.ResFailed(entities.ErrProxyCreateRequestFailed, e1.Error()))
 		return
 	}
-	proxyReq.Header = c.Request.Header
+	// html is decoded before rewriting, ask upstream only for encodings we can decode
+	proxyReq.Header.Set("Accept-Encoding", htmlAcceptEncoding)
 
-	client := &http.Client{}
-	proxyRes, e2 := client.Do(proxyReq)
+	proxyRes, e2 := app.Proxy().Do(proxyReq)
 	if e2 != nil {
-		c.JSON(http.StatusBadRequest, entities.ResFailed(entities.ErrProxyRequestFailed, e2.Error()))
+		abortProxyRequestFailed(c, e2)
 		return
 	}
 	defer func() {
 		_ = proxyRes.Body.Close()
 	}()
 
-	resBody, e3 := replaceRelativePathInRes(params.Url, proxyRes)
-	if e3 != nil {
-		c.JSON(http.StatusBadRequest, entities.ResFailed(entities.ErrProxyParseResBodyFailed, e3.Error()))
+	// only html is rewritten, other resources are streamed as is
+	if !isHtmlResponse(proxyRes) {
+		streamProxyResponse(c, proxyRes)
 		return
 	}
 
-	for key, value := range proxyRes.Header {
-		if len(value) == 1 && !isIgnoreHeader(key, htmlIgnoreHeaderKeys) {
-			c.Writer.Header().Add(key, value[0])
-		}
-	}
-
-	c.Status(proxyRes.StatusCode)
-	_, _ = c.Writer.Write([]byte(resBody))
+	rewriteHtmlRes(c, proxyRes)
 }
 
-func ProxyGetNextRes(c *gin.Context) {
-	realUrl := "https://pancakeswap.finance" + c.Request.URL.Path
-	log.Printf("Proxy res url: %s", realUrl)
-	req, e1 := http.NewRequest(c.Request.Method, realUrl, c.Request.Body)
+// ProxyGetSiteRes Request static resource of an embedded site, the site is chosen by route prefix
+func ProxyGetSiteRes(c *gin.Context) {
+	prefix := strings.TrimSuffix(c.FullPath(), "/*any")
+	site := app.Proxy().MatchSite(prefix, c.Request.Referer())
+	if site == nil {
+		c.JSON(http.StatusNotFound, middleware.ResFailed(c, entities.ErrProxyUrlNotAllowed, prefix))
+		return
+	}
+
+	realUrl := site.TargetURL(c.Request.URL.Path, c.Request.URL.RawQuery)
+	log.Printf("Proxy %s res url: %s", site.Name, realUrl)
+	req, e1 := newProxyRequest(c, realUrl)
 	if e1 != nil {
-		c.JSON(http.StatusBadRequest, entities.ResFailed(entities.ErrProxyCreateRequestFailed, e1.Error()))
 		return
 	}
-	req.Header = c.Request.Header
+	site.ApplyRequestHeaders(req.Header)
 
-	client := &http.Client{}
-	resp, e2 := client.Do(req)
-	if e2 != nil {
-		c.JSON(http.StatusBadRequest, entities.ResFailed(entities.ErrProxyRequestFailed, e2.Error()))
+	if !app.Proxy().AssetCacheEnabled || !proxies.IsCacheableRequest(c.Request) {
+		app.Cache().ProxyAssetStatIncr(caches.ProxyAssetStatBypass)
+		c.Header(proxyCacheHeader, "BYPASS")
+		forwardSiteRes(c, site, req)
+		return
+	}
+	serveCachedSiteRes(c, site, req)
+}
+
+// forwardSiteRes stream site resource from upstream without cache
+func forwardSiteRes(c *gin.Context, site *proxies.Site, req *http.Request) {
+	resp, e0 := app.Proxy().Do(req)
+	if e0 != nil {
+		abortProxyRequestFailed(c, e0)
 		return
 	}
 	defer func() {
 		_ = resp.Body.Close()
 	}()
 
-	body, e3 := io.ReadAll(resp.Body)
-	if e3 != nil {
-		c.JSON(http.StatusBadRequest, entities.ResFailed(entities.ErrProxyParseResBodyFailed, e3.Error()))
+	site.ApplyResponseHeaders(resp.Header)
+	streamProxyResponse(c, resp)
+}
+
+// serveCachedSiteRes serve site resource from cache, stale entries are revalidated with upstream validators
+func serveCachedSiteRes(c *gin.Context, site *proxies.Site, req *http.Request) {
+	encoding := proxies.CacheEncoding(c.Request)
+	key := caches.GenProxyAssetCacheKey(encoding, req.URL.String())
+	proxies.PrepareCacheRequest(req, encoding)
+
+	now := time.Now()
+	asset, e0 := app.Cache().ProxyAssetGet(key)
+	if e0 != nil {
+		asset = nil
+	} else if asset.IsFresh(now) {
+		app.Cache().ProxyAssetStatIncr(caches.ProxyAssetStatHit)
+		writeCachedAsset(c, site, asset, "HIT")
 		return
+	} else {
+		proxies.SetValidators(req, asset.Header)
 	}
 
-	for key, value := range resp.Header {
-		if len(value) == 1 && !isIgnoreHeader(key, reqIgnoreHeaderKeys) {
-			c.Writer.Header().Add(key, value[0])
+	resp, e1 := app.Proxy().Do(req)
+	if e1 != nil {
+		abortProxyRequestFailed(c, e1)
+		return
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode == http.StatusNotModified && asset != nil {
+		app.Cache().ProxyAssetStatIncr(caches.ProxyAssetStatRevalidated)
+		// 304 carries updated freshness headers of the stored response
+		for _, key := range []string{"Cache-Control", "Expires", "Date", "ETag", "Last-Modified"} {
+			if values := resp.Header.Values(key); len(values) > 0 {
+				asset.Header[key] = values
+			}
 		}
+		fresh, _ := proxies.Freshness(&http.Response{StatusCode: asset.StatusCode, Header: asset.Header}, now)
+		storeCachedAsset(key, asset, fresh, now)
+		writeCachedAsset(c, site, asset, "REVALIDATED")
+		return
+	}
+
+	app.Cache().ProxyAssetStatIncr(caches.ProxyAssetStatMiss)
+	maxEntry := app.Proxy().AssetCacheMaxEntry
+	fresh, storable := proxies.Freshness(resp, now)
+	if !storable || resp.ContentLength > maxEntry {
+		c.Header(proxyCacheHeader, "MISS")
+		site.ApplyResponseHeaders(resp.Header)
+		streamProxyResponse(c, resp)
+		return
+	}
+
+	body, e2 := io.ReadAll(io.LimitReader(resp.Body, maxEntry+1))
+	if e2 != nil {
+		abortProxyRequestFailed(c, e2)
+		return
+	}
+	if int64(len(body)) > maxEntry {
+		// too large to cache, stream what is read and the rest of upstream body
+		resp.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(body), resp.Body), resp.Body}
+		c.Header(proxyCacheHeader, "MISS")
+		site.ApplyResponseHeaders(resp.Header)
+		streamProxyResponse(c, resp)
+		return
+	}
+
+	asset = &caches.ProxyAsset{StatusCode: resp.StatusCode, Header: make(http.Header), Body: body}
+	proxies.CopyHeaders(asset.Header, resp.Header, "content-length")
+	storeCachedAsset(key, asset, fresh, now)
+	writeCachedAsset(c, site, asset, "MISS")
+}
+
+// storeCachedAsset save asset fresh for fresh (capped by max cache age), it is kept for max cache age for revalidation
+func storeCachedAsset(key string, asset *caches.ProxyAsset, fresh time.Duration, now time.Time) {
+	maxAge := app.Proxy().AssetCacheMaxAge
+	asset.StoredAt = now.Unix()
+	asset.FreshUntil = now.Add(min(fresh, maxAge)).Unix()
+	if e0 := app.Cache().ProxyAssetSet(key, asset, int(maxAge/time.Second)); e0 != nil {
+		log.Printf("Proxy cache asset %s failed: %s", key, e0)
+	}
+}
+
+// writeCachedAsset response cached asset, 304 if client validators match
+func writeCachedAsset(c *gin.Context, site *proxies.Site, asset *caches.ProxyAsset, cacheStatus string) {
+	header := asset.Header.Clone()
+	site.ApplyResponseHeaders(header)
+	proxies.CopyHeaders(c.Writer.Header(), header)
+	c.Header(proxyCacheHeader, cacheStatus)
+	c.Header("Age", strconv.FormatInt(max(time.Now().Unix()-asset.StoredAt, 0), 10))
+
+	if proxies.NotModified(c.Request, header) {
+		c.Status(http.StatusNotModified)
+		return
 	}
-	c.Status(resp.StatusCode)
-	_, _ = c.Writer.Write(body)
+	c.Header("Content-Length", strconv.Itoa(len(asset.Body)))
+	c.Status(asset.StatusCode)
+	_, _ = c.Writer.Write(asset.Body)
+}
+
+// GetProxyCacheStats
+// @Tags Admin
+// @Router /admin/proxy/cache/stats [get]
+// @Summary Admin get proxy asset cache counters
+// @description Admin get hit, revalidated, miss and bypass counts of embedded site asset cache
+func GetProxyCacheStats(c *gin.Context) {
+	stats, e0 := app.Cache().ProxyAssetStats()
+	if e0 != nil {
+		c.JSON(http.StatusInternalServerError, middleware.ResFailedError(c, entities.ErrInternalCacheQueryFailed, e0))
+		return
+	}
+	c.JSON(http.StatusOK, entities.ResSuccess(stats))
 }
 
 func ProxyRequest(c *gin.Context) {
 	var params entities.ProxyGetParam
 	if e0 := c.ShouldBindQuery(&params); e0 != nil {
-		c.JSON(http.StatusBadRequest, entities.ResFailed(entities.ErrInvalidParams, e0.Error()))
+		c.JSON(http.StatusBadRequest, middleware.ResFailed(c, entities.ErrInvalidParams, e0.Error()))
 		return
 	}
 
-	req, e1 := http.NewRequest(c.Request.Method, params.Url, c.Request.Body)
+	req, e1 := newGuardedRequest(c, params.Url)
 	if e1 != nil {
-		c.JSON(http.StatusBadRequest, entities.ResFailed(entities.ErrProxyCreateRequestFailed, e1.Error()))
 		return
 	}
-	req.Header = c.Request.Header
 
-	client := &http.Client{}
-	resp, e2 := client.Do(req)
+	resp, e2 := app.Proxy().Do(req)
 	if e2 != nil {
-		c.JSON(http.StatusBadRequest, entities.ResFailed(entities.ErrProxyRequestFailed, e2.Error()))
+		abortProxyRequestFailed(c, e2)
 		return
 	}
 	defer func() {
 		_ = resp.Body.Close()
 	}()
 
-	body, e3 := io.ReadAll(resp.Body)
+	streamProxyResponse(c, resp)
+}
+
+// ProxyWebSocket Proxy websocket of embedded dApps to an allowed upstream, frames are copied both ways until
+// either side closes or the connection is idle
+func ProxyWebSocket(c *gin.Context) {
+	var params entities.ProxyGetParam
+	if e0 := c.ShouldBindQuery(&params); e0 != nil {
+		c.JSON(http.StatusBadRequest, middleware.ResFailed(c, entities.ErrInvalidParams, e0.Error()))
+		return
+	}
+	if !proxies.IsWebSocketRequest(c.Request) {
+		c.JSON(http.StatusBadRequest, middleware.ResFailed(c, entities.ErrInvalidParams, "websocket upgrade required"))
+		return
+	}
+	targetUrl, e1 := url.Parse(params.Url)
+	if e1 != nil {
+		c.JSON(http.StatusBadRequest, middleware.ResFailed(c, entities.ErrInvalidParams, e1.Error()))
+		return
+	}
+	httpUrl := proxies.WebSocketHttpURL(targetUrl)
+	if e2 := app.Proxy().Guard.CheckURL(httpUrl); e2 != nil {
+		c.JSON(http.StatusForbidden, middleware.ResFailed(c, entities.ErrProxyUrlNotAllowed, e2.Error()))
+		return
+	}
+
+	ip := c.RemoteIP()
+	if !app.Proxy().WsConns.Acquire(ip) {
+		c.JSON(http.StatusTooManyRequests, middleware.ResFailed(c, entities.ErrProxyTooManyConnections, ip))
+		return
+	}
+	defer app.Proxy().WsConns.Release(ip)
+
+	// request context stays valid until handler returns, even after the connection is hijacked
+	req, e3 := http.NewRequestWithContext(c.Request.Context(), http.MethodGet, httpUrl.String(), nil)
 	if e3 != nil {
-		c.JSON(http.StatusBadRequest, entities.ResFailed(entities.ErrProxyParseResBodyFailed, e3.Error()))
+		c.JSON(http.StatusBadRequest, middleware.ResFailedError(c, entities.ErrProxyCreateRequestFailed, e3))
 		return
 	}
+	proxies.CopyHeaders(req.Header, c.Request.Header, "cookie", "origin")
+	proxies.SetUpgradeHeaders(req.Header)
+	// upstream sees the handshake as coming from its own page
+	req.Header.Set("Origin", httpUrl.Scheme+"://"+httpUrl.Host)
 
-	for key, value := range resp.Header {
-		if len(value) == 1 && !isIgnoreHeader(key, reqIgnoreHeaderKeys) {
-			c.Writer.Header().Add(key, value[0])
-		}
+	res, upstream, e4 := app.Proxy().Upgrade(req)
+	if errors.Is(e4, proxies.ErrNotUpgraded) {
+		c.JSON(http.StatusBadGateway, middleware.ResFailedError(c, entities.ErrProxyUpgradeFailed, e4))
+		return
+	} else if e4 != nil {
+		abortProxyRequestFailed(c, e4)
+		return
+	}
+	defer func() {
+		_ = upstream.Close()
+	}()
+
+	conn, buf, e5 := c.Writer.Hijack()
+	if e5 != nil {
+		log.Printf("Proxy websocket %s hijack failed: %s", httpUrl, e5)
+		return
+	}
+	if e6 := proxies.WriteSwitchingProtocols(buf, res); e6 != nil {
+		log.Printf("Proxy websocket %s handshake failed: %s", httpUrl, e6)
+		_ = conn.Close()
+		return
 	}
-	c.Status(resp.StatusCode)
-	_, _ = c.Writer.Write(body)
+	proxies.Pipe(conn, buf.Reader, upstream, app.Proxy().WsIdleTimeout)
 }
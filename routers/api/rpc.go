@@ -0,0 +1,57 @@
+package api
+
+import (
+	"game-mining-server/app"
+	"game-mining-server/caches"
+	"game-mining-server/entities"
+	"game-mining-server/routers/middleware"
+	"game-mining-server/rpcs"
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis_rate/v10"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+const maxRpcRequestBytes = 1 << 20
+
+// RpcForward
+// @Tags Rpc
+// @Router /rpc/{chain} [post]
+// @Summary Forward json-rpc calls to chain nodes
+// @description Forward a json-rpc call or batch to configured nodes with failover, immutable results are cached
+func RpcForward(c *gin.Context) {
+	chain := app.Rpc().Chain(c.Param("chain"))
+	if chain == nil {
+		c.JSON(http.StatusNotFound, middleware.ResFailed(c, entities.ErrRpcChainNotFound, c.Param("chain")))
+		return
+	}
+
+	body, e0 := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxRpcRequestBytes))
+	if e0 != nil {
+		c.JSON(http.StatusRequestEntityTooLarge, middleware.ResFailed(c, entities.ErrInvalidParams, e0.Error()))
+		return
+	}
+	calls, isBatch, e1 := rpcs.ParseCalls(body)
+	if e1 != nil {
+		c.JSON(http.StatusOK, rpcs.NewErrorResponse(nil, rpcs.CodeParseError, e1.Error()))
+		return
+	}
+
+	// every call of a batch counts, anonymous wallets are limited by ip
+	limitKey := caches.GenRateLimitCacheKey("RPC", "IP:"+c.RemoteIP())
+	if user := middleware.CurrentRequestUser(c); user != nil {
+		limitKey = caches.GenRateLimitCacheKey("RPC", strconv.FormatInt(user.Id, 10))
+	}
+	limit, e2 := app.Cache().RateLimiter.AllowN(c, limitKey, redis_rate.PerMinute(app.Rpc().UserRatePerMin), max(len(calls), 1))
+	if e2 == nil && limit.Allowed == 0 {
+		c.JSON(http.StatusTooManyRequests, middleware.ResFailed(c, entities.ErrTooManyRequests, "too many rpc calls"))
+		return
+	}
+
+	if res := app.Rpc().Handle(c.Request.Context(), chain, calls, isBatch); res != nil {
+		c.JSON(http.StatusOK, res)
+	} else {
+		c.Status(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,89 @@
+package api
+
+import (
+	"errors"
+	"game-mining-server/achievements"
+	"game-mining-server/app"
+	"game-mining-server/configs"
+	"game-mining-server/dbs"
+	"game-mining-server/entities"
+	"game-mining-server/routers/middleware"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"net/http"
+	"time"
+)
+
+type TapRes struct {
+	Tap   *dbs.TapStatus `json:"tap"`
+	Point *dbs.Point     `json:"point,omitempty"`
+}
+
+// GetTapStatus
+// @Tags Tap
+// @Router /tap/status [get]
+// @Summary Get current user's energy and tap statistics
+// @description Get current user's energy regenerated until now, energy settings and tap statistics
+func GetTapStatus(c *gin.Context) {
+	user := middleware.CurrentRequestUser(c)
+	if user == nil {
+		c.JSON(http.StatusUnauthorized, middleware.ResFailed(c, entities.ErrUserNotFound, "unauthorized"))
+		return
+	}
+
+	tap, e0 := app.DB().TapFindOrCreate(user.Id, app.Config().Tap)
+	if e0 != nil {
+		c.JSON(http.StatusInternalServerError, middleware.ResFailedError(c, entities.ErrInternalDBQueryFailed, e0))
+		return
+	}
+	c.JSON(http.StatusOK, entities.ResSuccess(&TapRes{Tap: tap.Status(time.Now().UnixMilli(), app.Config().Tap)}))
+}
+
+// SubmitTaps
+// @Tags Tap
+// @Router /tap [post]
+// @Summary Current user submit a batch of taps
+// @description Submit taps counted by client between startedAt and endedAt, each tap costs 1 energy and earns points.
+// @description Taps exceeding energy are not credited, batches faster than a human can tap, in the future, too old or
+// @description overlapping a previous batch are rejected, and the user is flagged after too many rejected batches
+func SubmitTaps(c *gin.Context) {
+	user, params := middleware.CheckUserAndJsonParams[entities.TapParam](c)
+	if user == nil || params == nil {
+		return
+	}
+
+	tap, point, e0 := app.DB().TapSubmit(user.Id, params.Taps, params.StartedAt, params.EndedAt, app.Config().Tap)
+	switch {
+	case errors.Is(e0, dbs.ErrTapFlagged):
+		c.JSON(http.StatusForbidden, middleware.ResFailed(c, entities.ErrTapFlagged, e0.Error()))
+	case errors.Is(e0, dbs.ErrTapRejected):
+		c.JSON(http.StatusBadRequest, middleware.ResFailed(c, entities.ErrTapRejected, e0.Error()))
+	case e0 != nil:
+		c.JSON(http.StatusInternalServerError, middleware.ResFailedError(c, entities.ErrInternalDBUpdateFailed, e0))
+	default:
+		achievements.Emit(user.Id, configs.AchievementEventPoints)
+		c.JSON(http.StatusOK, entities.ResSuccess(&TapRes{Tap: tap, Point: point}))
+	}
+}
+
+// UnflagTapUser
+// @Tags Admin
+// @Router /admin/tap/{uid}/unflag [post]
+// @Summary Admin unflag a user flagged for impossible taps
+// @description Admin clear tap flag of a user after review, the user can tap again and rejects are counted from zero
+func UnflagTapUser(c *gin.Context) {
+	var params entities.TapUidParam
+	if e0 := c.ShouldBindUri(&params); e0 != nil {
+		c.JSON(http.StatusBadRequest, middleware.ResFailed(c, entities.ErrInvalidParams, e0.Error()))
+		return
+	}
+
+	tap, e1 := app.DB().TapUnflag(params.Uid)
+	if errors.Is(e1, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusBadRequest, middleware.ResFailed(c, entities.ErrInvalidParams, "tap user not found"))
+	} else if e1 != nil {
+		c.JSON(http.StatusInternalServerError, middleware.ResFailedError(c, entities.ErrInternalDBUpdateFailed, e1))
+	} else {
+		c.JSON(http.StatusOK, entities.ResSuccess(&TapRes{Tap: tap.Status(time.Now().UnixMilli(), app.Config().Tap)}))
+	}
+}
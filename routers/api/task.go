@@ -1,6 +1,7 @@
 package api
 
 import (
+	"game-mining-server/achievements"
 	"game-mining-server/app"
 	"game-mining-server/configs"
 	"game-mining-server/dbs"
@@ -9,41 +10,58 @@ import (
 	"github.com/gin-gonic/gin"
 	"net/http"
 	"strconv"
+	"time"
 )
 
 type UserTaskStatus struct {
-	SocialTasks      []*dbs.Task `json:"socialTasks"`
-	Point            *dbs.Point  `json:"point"`
-	InvitedUserCount int64       `json:"invitedUserCount"`
+	SocialTasks      []*dbs.Task          `json:"socialTasks"`
+	Point            *dbs.Point           `json:"point"`
+	InvitedUserCount int64                `json:"invitedUserCount"`
+	Mining           *dbs.MiningStatus    `json:"mining"`
+	Boosters         []*dbs.ActiveBooster `json:"boosters"`
 }
 
 // GetUserTaskStatus
 // @Tags Task
 // @Router /task/status [get]
 // @Summary Get current user all tasks status and related info
-// @description Get current user all tasks status and related info
+// @description Get current user all tasks status and related info, including idle mining, which starts on first call,
+// @description and active boosters
 func GetUserTaskStatus(c *gin.Context) {
 	user := middleware.CurrentRequestUser(c)
 	if user == nil {
-		c.JSON(http.StatusUnauthorized, entities.ResFailed(entities.ErrUserNotFound, "unauthorized"))
+		c.JSON(http.StatusUnauthorized, middleware.ResFailed(c, entities.ErrUserNotFound, "unauthorized"))
 		return
 	}
 
 	socialTasks, e0 := app.DB().TaskFindAllOrCreateSocial(user.Id)
 	if e0 != nil {
-		c.JSON(http.StatusBadRequest, entities.ResFailed(entities.ErrInternalDBQueryFailed, e0.Error()))
+		c.JSON(http.StatusBadRequest, middleware.ResFailedError(c, entities.ErrInternalDBQueryFailed, e0))
 		return
 	}
 
 	point, e1 := app.DB().PointFindByUid(user.Id)
 	if e1 != nil {
-		c.JSON(http.StatusBadRequest, entities.ResFailed(entities.ErrInternalDBQueryFailed, e1.Error()))
+		c.JSON(http.StatusBadRequest, middleware.ResFailedError(c, entities.ErrInternalDBQueryFailed, e1))
 		return
 	}
 
 	invitedCount, e2 := app.DB().UserCountInvitedUsers(user.Id)
 	if e2 != nil {
-		c.JSON(http.StatusBadRequest, entities.ResFailed(entities.ErrInternalDBQueryFailed, e2.Error()))
+		c.JSON(http.StatusBadRequest, middleware.ResFailedError(c, entities.ErrInternalDBQueryFailed, e2))
+		return
+	}
+
+	mining, e3 := app.DB().MiningFindOrCreate(user.Id, user.Perks(app.Config().Premium).ExtraMiningCapacity, app.Config().Mining)
+	if e3 != nil {
+		c.JSON(http.StatusBadRequest, middleware.ResFailedError(c, entities.ErrInternalDBQueryFailed, e3))
+		return
+	}
+
+	nowMs := time.Now().UnixMilli()
+	boosters, e4 := app.DB().BoosterFindActive(app.DB().DBInstance, user.Id, nowMs)
+	if e4 != nil {
+		c.JSON(http.StatusBadRequest, middleware.ResFailedError(c, entities.ErrInternalDBQueryFailed, e4))
 		return
 	}
 
@@ -51,6 +69,8 @@ func GetUserTaskStatus(c *gin.Context) {
 		SocialTasks:      socialTasks,
 		Point:            point,
 		InvitedUserCount: invitedCount,
+		Mining:           mining.Status(nowMs),
+		Boosters:         boosters,
 	}))
 }
 
@@ -67,23 +87,28 @@ func UserTaskClaim(c *gin.Context) {
 
 	var point *dbs.Point
 	var err error
+	event := configs.AchievementEventTask
 	if params.TaskGroup == configs.TaskGroupSocial {
 		// social claim, key is taskId
-		point, err = app.DB().TaskClaim(params.ClaimKey, user.Id, configs.TaskStatusClaimable, configs.TaskStatusClaimed)
+		point, err = app.DB().TaskClaim(params.ClaimKey, user.Id, configs.TaskStatusClaimable, configs.TaskStatusClaimed, app.Config())
 	} else if params.TaskGroup == configs.TaskGroupWallet {
 		// wallet claim, hash is not used for now
-		point, err = app.DB().PointClaimForWallet(user.Id, configs.TaskWalletBaseRewardPoint)
+		point, err = app.DB().PointClaimForWallet(user.Id, configs.TaskWalletBaseRewardPoint, app.Config())
 	} else if params.TaskGroup == configs.TaskGroupInvite {
 		// invite claim, key is level
 		if level, e := strconv.ParseInt(params.ClaimKey, 10, 64); e != nil {
-			c.JSON(http.StatusBadRequest, entities.ResFailed(entities.ErrInvalidParams, e.Error()))
+			c.JSON(http.StatusBadRequest, middleware.ResFailed(c, entities.ErrInvalidParams, e.Error()))
 		} else {
-			point, err = app.DB().PointClaimForInvite(user.Id, level)
+			point, err = app.DB().PointClaimForInvite(user.Id, level, app.Config())
+			event = configs.AchievementEventInvite
 		}
 	}
 	if err != nil {
-		c.JSON(http.StatusBadRequest, entities.ResFailed(entities.ErrInternalDBUpdateFailed, err.Error()))
+		c.JSON(http.StatusBadRequest, middleware.ResFailedError(c, entities.ErrInternalDBUpdateFailed, err))
 	} else {
+		if point != nil {
+			achievements.Emit(user.Id, event)
+		}
 		c.JSON(http.StatusOK, entities.ResSuccess(point))
 	}
 }
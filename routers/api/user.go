@@ -1,6 +1,7 @@
 package api
 
 import (
+	"game-mining-server/achievements"
 	"game-mining-server/app"
 	"game-mining-server/caches"
 	"game-mining-server/configs"
@@ -17,11 +18,12 @@ import (
 )
 
 type UserLoginRes struct {
-	User    *dbs.User    `json:"user"`
-	IsNew   bool         `json:"isNew"`
-	Session string       `json:"session"`
-	Secret  string       `json:"secret"`
-	Checkin *dbs.Checkin `json:"checkin,omitempty"`
+	User    *dbs.User              `json:"user"`
+	IsNew   bool                   `json:"isNew"`
+	Session string                 `json:"session"`
+	Secret  string                 `json:"secret"`
+	Checkin *dbs.Checkin           `json:"checkin,omitempty"`
+	Perks   *configs.PremiumConfig `json:"perks"`
 }
 
 type UserInvitedUserListRes struct {
@@ -45,40 +47,50 @@ func Login(c *gin.Context) {
 	// valid request body
 	var params entities.UserLoginParam
 	if e0 := c.ShouldBindJSON(&params); e0 != nil {
-		c.JSON(http.StatusBadRequest, entities.ResFailed(entities.ErrInvalidParams, e0.Error()))
+		c.JSON(http.StatusBadRequest, middleware.ResFailed(c, entities.ErrInvalidParams, e0.Error()))
 		return
 	}
 
 	// only valid on PROD env
 	if app.Config().Basic.Env == configs.EnvPROD && app.Bot() != nil {
 		if e1 := initdata.Validate(params.InitDataRaw, app.Bot().Token(), 24*time.Hour); e1 != nil {
-			c.JSON(http.StatusBadRequest, entities.ResFailed(entities.ErrInvalidInitData, e1.Error()))
+			c.JSON(http.StatusBadRequest, middleware.ResFailed(c, entities.ErrInvalidInitData, e1.Error()))
 			return
 		}
 	}
 
 	userInitData, e2 := initdata.Parse(params.InitDataRaw)
 	if e2 != nil {
-		c.JSON(http.StatusBadRequest, entities.ResFailed(entities.ErrParseInitDataFailed, e2.Error()))
+		c.JSON(http.StatusBadRequest, middleware.ResFailed(c, entities.ErrParseInitDataFailed, e2.Error()))
 		return
 	}
 	uid := userInitData.User.ID
-	user, isNew, checkin, e4 := userLoginAndCheckin(app.DB(), uid, params.Referral, params.RandPoint, &dbs.User{
+	newUser := &dbs.User{
 		Id:                uid,
 		Username:          userInitData.User.Username,
 		IsPremium:         userInitData.User.IsPremium,
 		LanguageCode:      userInitData.User.LanguageCode,
 		ReferralCode:      utils.GenReferralCode(uid),
-		RewardPoints:      200,
 		LastPointsRefresh: time.Now().UTC(),
-	})
+	}
+	perks := newUser.Perks(app.Config().Premium)
+	newUser.RewardPoints = perks.DailyRewardPoints
+	user, isNew, checkin, e4 := userLoginAndCheckin(app.DB(), uid, params.Referral, params.RandPoint, newUser)
 	if e4 != nil {
-		c.JSON(http.StatusInternalServerError, entities.ResFailed(entities.ErrInternalDBInsertFailed, e4.Error()))
+		c.JSON(http.StatusInternalServerError, middleware.ResFailedError(c, entities.ErrInternalDBInsertFailed, e4))
 		return
 	}
+	// a new user invited by referral counts for inviter's achievements
+	if isNew {
+		achievements.Emit(user.ReferralUid, configs.AchievementEventInvite)
+	}
+	// show checkin reward with premium multiplier, which is applied when claimed
+	if checkin != nil {
+		checkin.RewardPoint = utils.CalPointWithMultiplier(checkin.RewardPoint, perks.CheckinMultiplier)
+	}
 
 	// refresh rewardPoints when user login
-	e5 := app.DB().RefreshUserRewardPoints(uid)
+	e5 := app.DB().RefreshUserRewardPoints(uid, app.Config().Premium)
 	if e5 != nil {
 		log.Printf("RefreshUserRewardPoints error %v\n", e5)
 	}
@@ -89,12 +101,12 @@ func Login(c *gin.Context) {
 	basicConfig := app.Config().Basic
 	session, e6 := utils.GenSession(uid, basicConfig.SessionExpiresSec, basicConfig.SessionEncryptKey)
 	if e6 != nil {
-		c.JSON(http.StatusInternalServerError, entities.ResFailed(entities.ErrGenUserSessionFailed, e5.Error()))
+		c.JSON(http.StatusInternalServerError, middleware.ResFailedError(c, entities.ErrGenUserSessionFailed, e6))
 		return
 	}
 
 	if e7 := app.Cache().SetString(caches.GenUserSessionCacheKey(uid), session, basicConfig.SessionExpiresSec); e7 != nil {
-		c.JSON(http.StatusInternalServerError, entities.ResFailed(entities.ErrInternalDBInsertFailed, e6.Error()))
+		c.JSON(http.StatusInternalServerError, middleware.ResFailedError(c, entities.ErrInternalDBInsertFailed, e7))
 	} else {
 		c.JSON(http.StatusOK, entities.ResSuccess(&UserLoginRes{
 			User:    user,
@@ -102,6 +114,7 @@ func Login(c *gin.Context) {
 			Session: session,
 			Secret:  utils.GenUserSecret(user.Id),
 			Checkin: checkin,
+			Perks:   perks,
 		}))
 	}
 }
@@ -166,7 +179,7 @@ func userLoginAndCheckin(dbService *dbs.Service, uid int64, referral string, ran
 // @Tags User
 // @Router /user/claim [post]
 // @Summary Current user request claim daily checkin
-// @description Current user request claim daily checkin
+// @description Current user request claim daily checkin, premium users get more reward
 func CheckinClaim(c *gin.Context) {
 	user, params := middleware.CheckUserAndJsonParams[entities.UserCheckinClaimParam](c)
 	if user == nil || params == nil {
@@ -180,6 +193,8 @@ func CheckinClaim(c *gin.Context) {
 		if e1 := tx.Where("id = ? AND uid = ? AND status = ?", params.CheckinId, user.Id, configs.CheckinStatusUnclaimed).First(&checkin).Error; e1 != nil {
 			return e1
 		}
+		// premium users get more checkin reward, the claimed reward is saved
+		checkin.RewardPoint = utils.CalPointWithMultiplier(checkin.RewardPoint, user.Perks(app.Config().Premium).CheckinMultiplier)
 		if _point, e2 := app.DB().PointClaimTask(tx, checkin.Uid, checkin.RewardPoint); e2 != nil {
 			return e2
 		} else {
@@ -189,8 +204,9 @@ func CheckinClaim(c *gin.Context) {
 		return tx.Save(&checkin).Error
 	})
 	if e0 != nil {
-		c.JSON(http.StatusInternalServerError, entities.ResFailed(entities.ErrInternalDBUpdateFailed, e0.Error()))
+		c.JSON(http.StatusInternalServerError, middleware.ResFailedError(c, entities.ErrInternalDBUpdateFailed, e0))
 	} else {
+		achievements.Emit(user.Id, configs.AchievementEventCheckin)
 		c.JSON(http.StatusOK, entities.ResSuccess(*point))
 	}
 }
@@ -203,12 +219,12 @@ func CheckinClaim(c *gin.Context) {
 func GetUserPoint(c *gin.Context) {
 	user := middleware.CurrentRequestUser(c)
 	if user == nil {
-		c.JSON(http.StatusUnauthorized, entities.ResFailed(entities.ErrUserNotFound, "unauthorized"))
+		c.JSON(http.StatusUnauthorized, middleware.ResFailed(c, entities.ErrUserNotFound, "unauthorized"))
 		return
 	}
 	point, e0 := app.DB().PointFindByUid(user.Id)
 	if e0 != nil {
-		c.JSON(http.StatusInternalServerError, entities.ResFailed(entities.ErrInternalDBQueryFailed, e0.Error()))
+		c.JSON(http.StatusInternalServerError, middleware.ResFailedError(c, entities.ErrInternalDBQueryFailed, e0))
 	} else {
 		c.JSON(http.StatusOK, entities.ResSuccess(point))
 	}
@@ -226,7 +242,7 @@ func GetUserInvitedUserList(c *gin.Context) {
 	}
 	users, total, e0 := app.DB().UserFindInvitedUserList(user.Id, params)
 	if e0 != nil {
-		c.JSON(http.StatusBadRequest, entities.ResFailed(entities.ErrInternalDBQueryFailed, e0.Error()))
+		c.JSON(http.StatusBadRequest, middleware.ResFailedError(c, entities.ErrInternalDBQueryFailed, e0))
 	} else {
 		c.JSON(http.StatusOK, entities.ResSuccess(&UserInvitedUserListRes{
 			Users: users,
@@ -248,7 +264,7 @@ func GetLeaderboard(c *gin.Context) {
 
 	users, total, e := app.DB().PointGetLeaderBoardWithUser(params)
 	if e != nil {
-		c.JSON(http.StatusBadRequest, entities.ResFailed(entities.ErrInternalDBQueryFailed, e.Error()))
+		c.JSON(http.StatusBadRequest, middleware.ResFailedError(c, entities.ErrInternalDBQueryFailed, e))
 	} else {
 		c.JSON(http.StatusOK, entities.ResSuccess(&UserLeaderBoardRes{
 			Users: users,
@@ -1,49 +1,188 @@
 package api
 
 import (
+	"errors"
 	"game-mining-server/app"
-	"game-mining-server/caches"
+	"game-mining-server/configs"
 	"game-mining-server/entities"
+	"game-mining-server/prices"
+	"game-mining-server/routers/middleware"
 	"game-mining-server/utils"
 	"github.com/gin-gonic/gin"
+	"io"
 	"net/http"
-	"strconv"
 	"strings"
+	"time"
 )
 
+const (
+	maxStreamCoinSymbols  = 50
+	streamHeartbeatPeriod = 15 * time.Second // keep idle connections open through proxies
+)
+
+// fiatRate get usd exchange rate of fiat, response an error if fiat is not supported or rates are not loaded yet
+func fiatRate(c *gin.Context, fiatSymbol string) (float64, bool) {
+	if !app.Price().Fx.IsSupported(fiatSymbol) {
+		c.JSON(http.StatusBadRequest, middleware.ResFailed(c, entities.ErrPriceFiatNotSupported, fiatSymbol))
+		return 0, false
+	}
+	rate, ok := app.Price().Fx.Rate(fiatSymbol)
+	if !ok {
+		c.JSON(http.StatusServiceUnavailable, middleware.ResFailed(c, entities.ErrPriceUnavailable, fiatSymbol))
+	}
+	return rate, ok
+}
+
 func GetCoinPrice(c *gin.Context) {
 	var params entities.CoinPriceParam
 	if e0 := c.ShouldBindQuery(&params); e0 != nil {
-		c.JSON(http.StatusBadRequest, entities.ResFailed(entities.ErrInvalidParams, e0.Error()))
+		c.JSON(http.StatusBadRequest, middleware.ResFailed(c, entities.ErrInvalidParams, e0.Error()))
+		return
+	}
+
+	fxRate, ok := fiatRate(c, strings.ToUpper(params.FiatSymbol))
+	if !ok {
+		return
+	}
+
+	coinPriceMap, e1 := app.Price().GetCachedUSDPrices(c, strings.Split(params.CoinSymbols, ","))
+	if e1 != nil {
+		c.JSON(http.StatusBadGateway, middleware.ResFailedError(c, entities.ErrPriceUnavailable, e1))
+		return
+	}
+
+	for coinSymbol, price := range coinPriceMap {
+		coinPriceMap[coinSymbol] = price * fxRate
+	}
+	c.JSON(http.StatusOK, entities.ResSuccess(coinPriceMap))
+}
+
+// GetCoinPriceHistory Get 1h or 1d candles of a coin within a time range, prices are converted to fiat
+func GetCoinPriceHistory(c *gin.Context) {
+	var params entities.CoinPriceHistoryParam
+	if e0 := c.ShouldBindQuery(&params); e0 != nil {
+		c.JSON(http.StatusBadRequest, middleware.ResFailed(c, entities.ErrInvalidParams, e0.Error()))
+		return
+	}
+
+	fxRate, ok := fiatRate(c, strings.ToUpper(utils.Any(params.FiatSymbol == "", configs.FiatUSD, params.FiatSymbol)))
+	if !ok {
 		return
 	}
 
+	to := time.Now()
+	if params.To > 0 {
+		to = time.Unix(params.To, 0)
+	}
+	candles, e1 := app.Price().GetCandles(c, strings.ToUpper(params.CoinSymbol), params.Interval, time.Unix(params.From, 0), to)
+	if errors.Is(e1, prices.ErrInvalidInterval) || errors.Is(e1, prices.ErrInvalidRange) {
+		c.JSON(http.StatusBadRequest, middleware.ResFailed(c, entities.ErrInvalidParams, e1.Error()))
+		return
+	} else if e1 != nil {
+		c.JSON(http.StatusBadGateway, middleware.ResFailedError(c, entities.ErrPriceUnavailable, e1))
+		return
+	}
+
+	for i, candle := range candles {
+		converted := *candle
+		converted.Open, converted.High, converted.Low, converted.Close = candle.Open*fxRate, candle.High*fxRate, candle.Low*fxRate, candle.Close*fxRate
+		candles[i] = &converted
+	}
+	c.JSON(http.StatusOK, entities.ResSuccess(candles))
+}
+
+// GetPortfolioValue Value balances reported by client in fiat, with per coin breakdown and 24h change
+func GetPortfolioValue(c *gin.Context) {
+	var params entities.PortfolioParam
+	if e0 := c.ShouldBindJSON(&params); e0 != nil {
+		c.JSON(http.StatusBadRequest, middleware.ResFailed(c, entities.ErrInvalidParams, e0.Error()))
+		return
+	}
+
+	fiatSymbol := strings.ToUpper(utils.Any(params.FiatSymbol == "", configs.FiatUSD, params.FiatSymbol))
+	fxRate, ok := fiatRate(c, fiatSymbol)
+	if !ok {
+		return
+	}
+
+	holdings := make([]*prices.Holding, len(params.Balances))
+	for i, balance := range params.Balances {
+		holdings[i] = &prices.Holding{CoinSymbol: balance.CoinSymbol, Amount: balance.Amount}
+	}
+	portfolio, e1 := app.Price().Valuate(c, holdings, fiatSymbol, fxRate)
+	if e1 != nil {
+		c.JSON(http.StatusBadGateway, middleware.ResFailedError(c, entities.ErrPriceUnavailable, e1))
+		return
+	}
+	c.JSON(http.StatusOK, entities.ResSuccess(portfolio))
+}
+
+// StreamCoinPrice Push fiat prices of coins by server sent events, the first "price" event holds all known prices and
+// later ones only the coins whose price changed
+func StreamCoinPrice(c *gin.Context) {
+	var params entities.CoinPriceParam
+	if e0 := c.ShouldBindQuery(&params); e0 != nil {
+		c.JSON(http.StatusBadRequest, middleware.ResFailed(c, entities.ErrInvalidParams, e0.Error()))
+		return
+	}
+	fiatSymbol := strings.ToUpper(params.FiatSymbol)
+	if _, ok := fiatRate(c, fiatSymbol); !ok {
+		return
+	}
 	coinSymbols := strings.Split(params.CoinSymbols, ",")
+	if len(coinSymbols) > maxStreamCoinSymbols {
+		c.JSON(http.StatusBadRequest, middleware.ResFailed(c, entities.ErrInvalidParams, "too many coin symbols"))
+		return
+	}
 
-	var coinPriceMap = make(map[string]float64)
-	var needRequestCoinSymbols = make([]string, 0)
-	for _, coinSymbol := range coinSymbols {
-		cachedPrice, e1 := app.Cache().GetString(caches.GenCoinPriceCacheKey(params.FiatSymbol, coinSymbol))
-		if e1 == nil && cachedPrice != "" { // cache hit, just return cache price
-			priceFloat, _ := strconv.ParseFloat(cachedPrice, 64)
-			coinPriceMap[coinSymbol] = priceFloat
-		} else {
-			needRequestCoinSymbols = append(needRequestCoinSymbols, coinSymbol)
-		}
+	initial, e1 := app.Price().GetCachedUSDPrices(c, coinSymbols)
+	if e1 != nil {
+		c.JSON(http.StatusBadGateway, middleware.ResFailedError(c, entities.ErrPriceUnavailable, e1))
+		return
 	}
+	sub := app.Price().Hub.Subscribe(coinSymbols, initial)
+	defer app.Price().Hub.Unsubscribe(sub)
 
-	if len(needRequestCoinSymbols) > 0 {
-		latestCoinPrices, e2 := utils.GetCoinUSDPrice(needRequestCoinSymbols)
-		if e2 != nil {
-			c.JSON(http.StatusBadRequest, entities.ResFailed(entities.ErrInvalidParams, e2.Error()))
-			return
+	// rates are refreshed in background, each event is converted with the latest one
+	toFiat := func(usdPrices map[string]float64) map[string]float64 {
+		rate, _ := app.Price().Fx.Rate(fiatSymbol)
+		fiatPrices := make(map[string]float64, len(usdPrices))
+		for coinSymbol, price := range usdPrices {
+			fiatPrices[coinSymbol] = price * rate
 		}
+		return fiatPrices
+	}
+
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no") // disable nginx buffering
+	c.SSEvent("price", toFiat(initial))
+	c.Writer.Flush()
 
-		for _, price := range latestCoinPrices {
-			_ = app.Cache().SetString(caches.GenCoinPriceCacheKey(params.FiatSymbol, price.CoinSymbol), strconv.FormatFloat(price.Price, 'E', -1, 64), 60)
-			coinPriceMap[price.CoinSymbol] = price.Price
+	heartbeat := time.NewTicker(streamHeartbeatPeriod)
+	defer heartbeat.Stop()
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case prices := <-sub.C:
+			c.SSEvent("price", toFiat(prices))
+		case <-heartbeat.C:
+			c.SSEvent("ping", time.Now().Unix())
 		}
-	}
+		return true
+	})
+}
 
-	c.JSON(http.StatusOK, entities.ResSuccess(coinPriceMap))
+// GetSupportedFiats Get fiat symbols prices can be converted to, with their usd exchange rates
+func GetSupportedFiats(c *gin.Context) {
+	c.JSON(http.StatusOK, entities.ResSuccess(app.Price().Fx.Fiats()))
+}
+
+// GetPriceProviderHealth
+// @Tags Admin
+// @Router /admin/price/providers [get]
+// @Summary Admin get price provider health
+// @description Admin get consecutive failures, last error and cooldown of each price provider
+func GetPriceProviderHealth(c *gin.Context) {
+	c.JSON(http.StatusOK, entities.ResSuccess(app.Price().Health()))
 }
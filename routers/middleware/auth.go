@@ -45,7 +45,7 @@ func AuthMiddleware(allowPublic bool) gin.HandlerFunc {
 			ctx.Set(configs.CurUser, nil)
 			ctx.Next()
 		} else if user, code, msg := parseAuthUser(authHeader); code != entities.Ok || user == nil {
-			ctx.JSON(http.StatusUnauthorized, entities.ResFailed(code, msg))
+			ctx.JSON(http.StatusUnauthorized, ResFailed(ctx, code, msg))
 			ctx.Abort()
 		} else {
 			ctx.Set(configs.CurUser, *user)
@@ -54,6 +54,18 @@ func AuthMiddleware(allowPublic bool) gin.HandlerFunc {
 	}
 }
 
+// AdminMiddleware only allow admin users, must be used after AuthMiddleware
+func AdminMiddleware() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if user := CurrentRequestUser(ctx); user == nil || !app.Config().Bot.IsAdmin(user.Id) {
+			ctx.JSON(http.StatusForbidden, ResFailed(ctx, entities.ErrPermissionDenied, "admin only"))
+			ctx.Abort()
+		} else {
+			ctx.Next()
+		}
+	}
+}
+
 func CurrentRequestUser(c *gin.Context) *dbs.User {
 	userData, exist := c.Get(configs.CurUser)
 	if userData == nil || !exist {
@@ -66,12 +78,12 @@ func CurrentRequestUser(c *gin.Context) *dbs.User {
 func CheckUserAndJsonParams[T any](c *gin.Context) (*dbs.User, *T) {
 	user := CurrentRequestUser(c)
 	if user == nil {
-		c.JSON(http.StatusUnauthorized, entities.ResFailed(entities.ErrUserNotFound, "unauthorized"))
+		c.JSON(http.StatusUnauthorized, ResFailed(c, entities.ErrUserNotFound, "unauthorized"))
 		return nil, nil
 	}
 	var params T
 	if err := c.ShouldBindJSON(&params); err != nil {
-		c.JSON(http.StatusBadRequest, entities.ResFailed(entities.ErrInvalidParams, err.Error()))
+		c.JSON(http.StatusBadRequest, ResFailed(c, entities.ErrInvalidParams, err.Error()))
 		return nil, nil
 	}
 	return user, &params
@@ -80,12 +92,12 @@ func CheckUserAndJsonParams[T any](c *gin.Context) (*dbs.User, *T) {
 func CheckUserAndQueryParams[T any](c *gin.Context) (*dbs.User, *T) {
 	user := CurrentRequestUser(c)
 	if user == nil {
-		c.JSON(http.StatusUnauthorized, entities.ResFailed(entities.ErrUserNotFound, "unauthorized"))
+		c.JSON(http.StatusUnauthorized, ResFailed(c, entities.ErrUserNotFound, "unauthorized"))
 		return nil, nil
 	}
 	var params T
 	if err := c.ShouldBindQuery(&params); err != nil {
-		c.JSON(http.StatusBadRequest, entities.ResFailed(entities.ErrInvalidParams, err.Error()))
+		c.JSON(http.StatusBadRequest, ResFailed(c, entities.ErrInvalidParams, err.Error()))
 		return nil, nil
 	}
 	return user, &params
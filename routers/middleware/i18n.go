@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"game-mining-server/entities"
+	"game-mining-server/i18n"
+	"github.com/gin-gonic/gin"
+	"log"
+)
+
+// RequestLanguage resolve request language, use current user's language code first, then Accept-Language header
+func RequestLanguage(c *gin.Context) string {
+	if user := CurrentRequestUser(c); user != nil && user.LanguageCode != "" {
+		return i18n.Normalize(user.LanguageCode)
+	}
+	return i18n.Normalize(c.GetHeader("Accept-Language"))
+}
+
+// ResFailed create a failed response with localized message by error code, raw error message is kept in detail
+func ResFailed(c *gin.Context, code int, detail string) entities.BaseResponse {
+	return entities.ResFailedDetail(code, i18n.Err(RequestLanguage(c), code), detail)
+}
+
+// ResFailedError create a failed response with localized message by error code, raw error is logged instead of
+// returned, since database, cache and upstream errors may expose internals
+func ResFailedError(c *gin.Context, code int, err error) entities.BaseResponse {
+	log.Printf("%s %s failed with code %d: %v\n", c.Request.Method, c.FullPath(), code, err)
+	return entities.ResFailedDetail(code, i18n.Err(RequestLanguage(c), code), "")
+}
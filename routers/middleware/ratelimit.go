@@ -33,7 +33,7 @@ func createIpRateLimiter(rate int) gin.HandlerFunc {
 			return
 		}
 		if res.Allowed == 0 {
-			ctx.JSON(http.StatusTooManyRequests, entities.ResFailed(entities.ErrTooManyRequests, "too many requests"))
+			ctx.JSON(http.StatusTooManyRequests, ResFailed(ctx, entities.ErrTooManyRequests, "too many requests"))
 			ctx.Abort()
 		} else {
 			ctx.Next()
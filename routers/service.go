@@ -2,6 +2,7 @@ package routers
 
 import (
 	"fmt"
+	"game-mining-server/app"
 	"game-mining-server/configs"
 	"game-mining-server/routers/api"
 	"game-mining-server/routers/middleware"
@@ -19,7 +20,7 @@ func CorsConfig(env string) gin.HandlerFunc {
 		corsConf.AllowHeaders = []string{"Authorization", "Content-Type", "Upgrade", "Origin", "Connection", "Accept-Encoding", "Accept-Language", "Host"}
 	} else {
 		corsConf.AllowMethods = []string{"GET", "POST", "DELETE", "OPTIONS", "PUT"}
-		corsConf.AllowHeaders = []string{"Authorization", "Content-Type", "Origin", "Connection", "Accept-Encoding", "Accept-Language", "Host"}
+		corsConf.AllowHeaders = []string{"Authorization", "Content-Type", "Upgrade", "Origin", "Connection", "Accept-Encoding", "Accept-Language", "Host"}
 	}
 
 	corsConf.AllowAllOrigins = true
@@ -27,8 +28,8 @@ func CorsConfig(env string) gin.HandlerFunc {
 	return cors.New(corsConf)
 }
 
-func InitAndRun(config *configs.BasicConfig) error {
-	env := config.Env
+func InitAndRun(config *configs.Config) error {
+	env := config.Basic.Env
 
 	gin.SetMode(getGinMode(env))
 
@@ -40,14 +41,23 @@ func InitAndRun(config *configs.BasicConfig) error {
 	r.Use(CorsConfig(env))
 
 	bindProxyApi(r)
-	bindNextApi(r)
+	bindSiteApi(r)
 	bindWalletApi(r)
+	bindRpcApi(r)
+
+	bindUserApi(r, config.Basic.Version)
+	bindTaskApi(r, config.Basic.Version)
+	bindMomentApi(r, config.Basic.Version)
+	bindAlertApi(r, config.Basic.Version)
+	bindMiningApi(r, config.Basic.Version)
+	bindTapApi(r, config.Basic.Version)
+	bindBoosterApi(r, config.Basic.Version)
+	bindAchievementApi(r, config.Basic.Version)
+	bindAdminApi(r, config.Basic.Version)
 
-	bindUserApi(r, config.Version)
-	bindTaskApi(r, config.Version)
-	bindMomentApi(r, config.Version)
+	bindBotWebhook(r, config.Bot)
 
-	return r.Run(fmt.Sprintf(":%d", config.Port))
+	return r.Run(fmt.Sprintf(":%d", config.Basic.Port))
 }
 
 func getGinMode(env string) string {
@@ -61,21 +71,39 @@ func getGinMode(env string) string {
 	}
 }
 
+func bindBotWebhook(r *gin.Engine, config *configs.BotConfig) {
+	if config.Mode != configs.BotModeWebhook {
+		return
+	}
+	r.POST(config.WebhookPath, api.BotWebhook)
+}
+
 func bindProxyApi(r *gin.Engine) {
 	group := r.Group("/proxy")
 	group.GET("/html", middleware.LimitIp480PerMinMiddleware(), api.ProxyGetHtml)
 	group.Match([]string{http.MethodPost, http.MethodGet, http.MethodOptions}, "/req", middleware.LimitIp480PerMinMiddleware(), api.ProxyRequest)
+	group.GET("/ws", middleware.LimitIp480PerMinMiddleware(), api.ProxyWebSocket)
 }
 
-func bindNextApi(r *gin.Engine) {
-	group := r.Group("/_next")
-	group.GET("/*any", middleware.LimitIp480PerMinMiddleware(), api.ProxyGetNextRes)
-
+// bindSiteApi serve static resources of embedded sites, e.g. /_next/* of pancakeswap
+func bindSiteApi(r *gin.Engine) {
+	for _, prefix := range app.Proxy().Prefixes() {
+		group := r.Group(prefix)
+		group.GET("/*any", middleware.LimitIp480PerMinMiddleware(), api.ProxyGetSiteRes)
+	}
 }
 
 func bindWalletApi(r *gin.Engine) {
 	group := r.Group("/wallet")
 	group.GET("/price", middleware.LimitIp240PerMinMiddleware(), api.GetCoinPrice)
+	group.GET("/price/stream", middleware.LimitIp30PerMinMiddleware(), api.StreamCoinPrice)
+	group.GET("/price/history", middleware.LimitIp120PerMinMiddleware(), api.GetCoinPriceHistory)
+	group.GET("/fiats", middleware.LimitIp120PerMinMiddleware(), api.GetSupportedFiats)
+	group.POST("/portfolio", middleware.LimitIp120PerMinMiddleware(), api.GetPortfolioValue)
+}
+
+func bindRpcApi(r *gin.Engine) {
+	r.POST("/rpc/:chain", middleware.LimitIp480PerMinMiddleware(), middleware.AuthMiddleware(true), api.RpcForward)
 }
 
 func bindUserApi(r *gin.Engine, version int) {
@@ -85,6 +113,8 @@ func bindUserApi(r *gin.Engine, version int) {
 	group.GET("/invited", middleware.LimitIp120PerMinMiddleware(), middleware.AuthMiddleware(false), api.GetUserInvitedUserList)
 	group.GET("/point", middleware.LimitIp120PerMinMiddleware(), middleware.AuthMiddleware(false), api.GetUserPoint)
 	group.GET("/leaderboard", middleware.LimitIp120PerMinMiddleware(), middleware.AuthMiddleware(false), api.GetLeaderboard)
+	group.GET("/notification", middleware.LimitIp120PerMinMiddleware(), middleware.AuthMiddleware(false), api.GetNotificationPref)
+	group.POST("/notification", middleware.LimitIp60PerMinMiddleware(), middleware.AuthMiddleware(false), api.UpdateNotificationPref)
 }
 
 func bindTaskApi(r *gin.Engine, version int) {
@@ -105,3 +135,48 @@ func bindMomentApi(r *gin.Engine, version int) {
 	group.DELETE("/:id/like", middleware.AuthMiddleware(false), api.RollbackLikeMoment)
 	group.POST("/:id/reward", middleware.AuthMiddleware(false), api.RewardMoment)
 }
+
+func bindMiningApi(r *gin.Engine, version int) {
+	group := r.Group(fmt.Sprintf("/api/%d/mining", version))
+	group.POST("/claim", middleware.LimitIp60PerMinMiddleware(), middleware.AuthMiddleware(false), api.MiningClaim)
+	group.GET("/upgrades", middleware.LimitIp120PerMinMiddleware(), middleware.AuthMiddleware(false), api.GetMiningUpgrades)
+	group.POST("/upgrade", middleware.LimitIp60PerMinMiddleware(), middleware.AuthMiddleware(false), api.MiningBuyUpgrade)
+}
+
+func bindTapApi(r *gin.Engine, version int) {
+	group := r.Group(fmt.Sprintf("/api/%d/tap", version))
+	group.GET("/status", middleware.LimitIp120PerMinMiddleware(), middleware.AuthMiddleware(false), api.GetTapStatus)
+	group.POST("", middleware.LimitIp120PerMinMiddleware(), middleware.AuthMiddleware(false), api.SubmitTaps)
+}
+
+func bindBoosterApi(r *gin.Engine, version int) {
+	group := r.Group(fmt.Sprintf("/api/%d/booster", version))
+	group.GET("/list", middleware.LimitIp120PerMinMiddleware(), middleware.AuthMiddleware(false), api.GetBoosters)
+	group.POST("/buy", middleware.LimitIp60PerMinMiddleware(), middleware.AuthMiddleware(false), api.BuyBooster)
+}
+
+func bindAchievementApi(r *gin.Engine, version int) {
+	group := r.Group(fmt.Sprintf("/api/%d/achievement", version))
+	group.GET("/badges", middleware.LimitIp120PerMinMiddleware(), middleware.AuthMiddleware(false), api.GetAchievementBadges)
+	group.GET("/progress", middleware.LimitIp120PerMinMiddleware(), middleware.AuthMiddleware(false), api.GetAchievementProgress)
+}
+
+func bindAlertApi(r *gin.Engine, version int) {
+	group := r.Group(fmt.Sprintf("/api/%d/alert", version))
+	group.POST("/create", middleware.LimitIp60PerMinMiddleware(), middleware.AuthMiddleware(false), api.CreatePriceAlert)
+	group.GET("/list", middleware.LimitIp120PerMinMiddleware(), middleware.AuthMiddleware(false), api.GetPriceAlerts)
+	group.POST("/:id/update", middleware.LimitIp60PerMinMiddleware(), middleware.AuthMiddleware(false), api.UpdatePriceAlert)
+	group.DELETE("/:id", middleware.LimitIp60PerMinMiddleware(), middleware.AuthMiddleware(false), api.DeletePriceAlert)
+}
+
+func bindAdminApi(r *gin.Engine, version int) {
+	group := r.Group(fmt.Sprintf("/api/%d/admin", version), middleware.LimitIp60PerMinMiddleware(), middleware.AuthMiddleware(false), middleware.AdminMiddleware())
+	group.POST("/broadcast/create", api.CreateBroadcast)
+	group.GET("/broadcast/:id", api.GetBroadcast)
+	group.POST("/broadcast/:id/pause", api.PauseBroadcast)
+	group.POST("/broadcast/:id/resume", api.ResumeBroadcast)
+	group.POST("/broadcast/:id/cancel", api.CancelBroadcast)
+	group.GET("/proxy/cache/stats", api.GetProxyCacheStats)
+	group.GET("/price/providers", api.GetPriceProviderHealth)
+	group.POST("/tap/:uid/unflag", api.UnflagTapUser)
+}
@@ -0,0 +1,93 @@
+package rpcs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"game-mining-server/caches"
+	"strconv"
+	"strings"
+)
+
+// cachedResult find the cached result of an immutable call, nil if not cached
+func (s *Service) cachedResult(chain *Chain, call *Request) json.RawMessage {
+	if !immutableMethods[call.Method] && !confirmedMethods[call.Method] {
+		return nil
+	}
+	cached, e0 := s.cache.GetString(caches.GenRpcResultCacheKey(chain.Name, call.Method, compactParams(call.Params)))
+	if e0 != nil || cached == "" {
+		return nil
+	}
+	return json.RawMessage(cached)
+}
+
+// storeResult cache the result of an immutable call, transactions are cached only after enough confirmations
+func (s *Service) storeResult(ctx context.Context, chain *Chain, call *Request, result json.RawMessage) {
+	if immutableMethods[call.Method] {
+		s.setResult(chain, call, result)
+		return
+	}
+	if !confirmedMethods[call.Method] {
+		return
+	}
+
+	var tx struct {
+		BlockNumber string `json:"blockNumber"`
+	}
+	if e0 := json.Unmarshal(result, &tx); e0 != nil || tx.BlockNumber == "" {
+		return // null result or pending transaction
+	}
+	txBlock, e1 := parseQuantity(tx.BlockNumber)
+	if e1 != nil {
+		return
+	}
+	latest, e2 := s.blockNumber(ctx, chain)
+	if e2 != nil || latest-txBlock < s.confirmationBlocks {
+		return
+	}
+	s.setResult(chain, call, result)
+}
+
+func (s *Service) setResult(chain *Chain, call *Request, result json.RawMessage) {
+	_ = s.cache.SetString(caches.GenRpcResultCacheKey(chain.Name, call.Method, compactParams(call.Params)), string(result), immutableCacheSec)
+}
+
+// blockNumber latest block number of chain, shared by requests for a few seconds
+func (s *Service) blockNumber(ctx context.Context, chain *Chain) (int64, error) {
+	key := caches.GenRpcBlockNumberCacheKey(chain.Name)
+	if cached, e0 := s.cache.GetString(key); e0 == nil && cached != "" {
+		return strconv.ParseInt(cached, 10, 64)
+	}
+
+	responses, e1 := s.call(ctx, chain, []*Request{{JsonRpc: jsonRpcVersion, Id: json.RawMessage("1"), Method: "eth_blockNumber"}})
+	if e1 != nil {
+		return 0, e1
+	}
+	if responses[0].Error != nil {
+		return 0, ErrNodeUnavailable
+	}
+	var quantity string
+	if e2 := json.Unmarshal(responses[0].Result, &quantity); e2 != nil {
+		return 0, e2
+	}
+	number, e3 := parseQuantity(quantity)
+	if e3 != nil {
+		return 0, e3
+	}
+	_ = s.cache.SetString(key, strconv.FormatInt(number, 10), blockNumberCacheSec)
+	return number, nil
+}
+
+// parseQuantity parse json-rpc hex quantity, e.g: 0x1b4
+func parseQuantity(quantity string) (int64, error) {
+	return strconv.ParseInt(strings.TrimPrefix(quantity, "0x"), 16, 64)
+}
+
+// compactParams normalize params so equal calls share a cache key
+func compactParams(params json.RawMessage) string {
+	var buf bytes.Buffer
+	if json.Compact(&buf, params) != nil {
+		return string(params)
+	}
+	return buf.String()
+}
@@ -0,0 +1,66 @@
+package rpcs
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+const jsonRpcVersion = "2.0"
+
+// json-rpc error codes
+const (
+	CodeParseError       = -32700
+	CodeInvalidRequest   = -32600
+	CodeMethodNotAllowed = -32601
+	CodeInternalError    = -32603
+	CodeNodeUnavailable  = -32000
+)
+
+// Request A json-rpc call, Id is kept raw so client ids of any type are echoed back as is
+type Request struct {
+	JsonRpc string          `json:"jsonrpc"`
+	Id      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+// Response A json-rpc result or error
+type Response struct {
+	JsonRpc string          `json:"jsonrpc"`
+	Id      json.RawMessage `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+}
+
+type Error struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+// NewErrorResponse create an error response, a missing id is answered with null
+func NewErrorResponse(id json.RawMessage, code int, message string) *Response {
+	if len(id) == 0 {
+		id = json.RawMessage("null")
+	}
+	return &Response{JsonRpc: jsonRpcVersion, Id: id, Error: &Error{Code: code, Message: message}}
+}
+
+// ParseCalls parse a single call or a batch, isBatch tells how the response must be shaped
+func ParseCalls(body []byte) (calls []*Request, isBatch bool, err error) {
+	body = bytes.TrimSpace(body)
+	if len(body) > 0 && body[0] == '[' {
+		err = json.Unmarshal(body, &calls)
+		return calls, true, err
+	}
+	var call Request
+	if err = json.Unmarshal(body, &call); err != nil {
+		return nil, false, err
+	}
+	return []*Request{&call}, false, nil
+}
+
+// isNotification a call without id expects no response
+func (r *Request) isNotification() bool {
+	return len(r.Id) == 0
+}
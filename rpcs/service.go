@@ -0,0 +1,293 @@
+package rpcs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"game-mining-server/caches"
+	"game-mining-server/configs"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	defaultTimeout            = 10 * time.Second
+	defaultNodeCooldown       = 30 * time.Second
+	defaultUserRatePerMin     = 600
+	defaultMaxBatchSize       = 50
+	defaultConfirmationBlocks = int64(15)
+	maxNodeResponseBytes      = int64(10 << 20)
+	immutableCacheSec         = 24 * 3600
+	blockNumberCacheSec       = 2
+)
+
+// defaultMethods read and send methods a wallet needs, filters and subscriptions are stateful and not allowed
+var defaultMethods = []string{
+	"eth_chainId", "net_version", "web3_clientVersion",
+	"eth_blockNumber", "eth_gasPrice", "eth_maxPriorityFeePerGas", "eth_feeHistory",
+	"eth_getBalance", "eth_getCode", "eth_getStorageAt", "eth_getTransactionCount",
+	"eth_call", "eth_estimateGas", "eth_getLogs",
+	"eth_getBlockByNumber", "eth_getBlockByHash",
+	"eth_getTransactionByHash", "eth_getTransactionReceipt",
+	"eth_sendRawTransaction",
+}
+
+// immutableMethods results never change for a chain
+var immutableMethods = map[string]bool{"eth_chainId": true, "net_version": true}
+
+// confirmedMethods results never change once their block has enough confirmations
+var confirmedMethods = map[string]bool{"eth_getTransactionReceipt": true, "eth_getTransactionByHash": true}
+
+var ErrNodeUnavailable = errors.New("no rpc node available")
+
+// Chain A chain with its upstream nodes and allowed methods
+type Chain struct {
+	Name    string
+	nodes   []*node
+	methods map[string]bool
+}
+
+type node struct {
+	url            string
+	unhealthyUntil atomic.Int64 // unix milli seconds, 0 if healthy
+}
+
+type Service struct {
+	chains             map[string]*Chain
+	client             *http.Client
+	cache              *caches.Service
+	nodeCooldown       time.Duration
+	confirmationBlocks int64
+	UserRatePerMin     int
+	MaxBatchSize       int
+}
+
+func CreateRpcService(cfg *configs.RpcConfig, cache *caches.Service) (*Service, error) {
+	s := &Service{
+		chains:             make(map[string]*Chain),
+		client:             &http.Client{Timeout: defaultTimeout},
+		cache:              cache,
+		nodeCooldown:       defaultNodeCooldown,
+		confirmationBlocks: defaultConfirmationBlocks,
+		UserRatePerMin:     defaultUserRatePerMin,
+		MaxBatchSize:       defaultMaxBatchSize,
+	}
+	if cfg == nil {
+		return s, nil
+	}
+	if cfg.TimeoutSec > 0 {
+		s.client.Timeout = time.Duration(cfg.TimeoutSec) * time.Second
+	}
+	if cfg.NodeCooldownSec > 0 {
+		s.nodeCooldown = time.Duration(cfg.NodeCooldownSec) * time.Second
+	}
+	if cfg.ConfirmationBlocks > 0 {
+		s.confirmationBlocks = cfg.ConfirmationBlocks
+	}
+	if cfg.UserRatePerMin > 0 {
+		s.UserRatePerMin = cfg.UserRatePerMin
+	}
+	if cfg.MaxBatchSize > 0 {
+		s.MaxBatchSize = cfg.MaxBatchSize
+	}
+
+	for _, chainCfg := range cfg.Chains {
+		if chainCfg.Name == "" || len(chainCfg.Nodes) == 0 {
+			return nil, fmt.Errorf("rpc chain %s has no name or nodes", chainCfg.Name)
+		}
+		chain := &Chain{Name: chainCfg.Name, methods: make(map[string]bool)}
+		for _, nodeUrl := range chainCfg.Nodes {
+			chain.nodes = append(chain.nodes, &node{url: nodeUrl})
+		}
+		methods := chainCfg.Methods
+		if len(methods) == 0 {
+			methods = defaultMethods
+		}
+		for _, method := range methods {
+			chain.methods[method] = true
+		}
+		s.chains[chain.Name] = chain
+	}
+	return s, nil
+}
+
+// Chain find a configured chain by name, nil if not found
+func (s *Service) Chain(name string) *Chain {
+	return s.chains[name]
+}
+
+// Handle answer calls from cache or upstream nodes, the result is a *Response for a single call, a []*Response for
+// a batch, or nil if all calls are notifications
+func (s *Service) Handle(ctx context.Context, chain *Chain, calls []*Request, isBatch bool) interface{} {
+	if isBatch && len(calls) == 0 {
+		return NewErrorResponse(nil, CodeInvalidRequest, "empty batch")
+	}
+	if len(calls) > s.MaxBatchSize {
+		return NewErrorResponse(nil, CodeInvalidRequest, fmt.Sprintf("batch is larger than %d", s.MaxBatchSize))
+	}
+
+	responses := make([]*Response, len(calls))
+	var pending []int
+	for i, call := range calls {
+		if call == nil || call.JsonRpc != jsonRpcVersion || call.Method == "" {
+			responses[i] = NewErrorResponse(nil, CodeInvalidRequest, "invalid request")
+		} else if !chain.methods[call.Method] {
+			responses[i] = NewErrorResponse(call.Id, CodeMethodNotAllowed, "method not allowed: "+call.Method)
+		} else if result := s.cachedResult(chain, call); result != nil {
+			responses[i] = &Response{JsonRpc: jsonRpcVersion, Id: call.Id, Result: result}
+		} else {
+			pending = append(pending, i)
+		}
+	}
+
+	if len(pending) > 0 {
+		s.forward(ctx, chain, calls, pending, responses)
+	}
+
+	var results []*Response
+	for i, call := range calls {
+		if call != nil && call.isNotification() && responses[i].Error == nil {
+			continue
+		}
+		results = append(results, responses[i])
+	}
+	if len(results) == 0 {
+		return nil
+	}
+	if !isBatch {
+		return results[0]
+	}
+	return results
+}
+
+// forward send pending calls upstream as one batch, client ids are replaced by call indexes to match responses
+func (s *Service) forward(ctx context.Context, chain *Chain, calls []*Request, pending []int, responses []*Response) {
+	upstreamCalls := make([]*Request, 0, len(pending))
+	for _, i := range pending {
+		upstreamCalls = append(upstreamCalls, &Request{
+			JsonRpc: jsonRpcVersion,
+			Id:      json.RawMessage(strconv.Itoa(i)),
+			Method:  calls[i].Method,
+			Params:  calls[i].Params,
+		})
+	}
+
+	upstreamResponses, e0 := s.call(ctx, chain, upstreamCalls)
+	if e0 != nil {
+		// node errors may contain node urls with api keys, so they are only logged
+		log.Printf("Rpc %s call failed: %s\n", chain.Name, e0)
+		for _, i := range pending {
+			responses[i] = NewErrorResponse(calls[i].Id, CodeNodeUnavailable, "node unavailable")
+		}
+		return
+	}
+
+	for _, res := range upstreamResponses {
+		i, e1 := strconv.Atoi(string(res.Id))
+		if e1 != nil || i < 0 || i >= len(calls) || responses[i] != nil {
+			continue
+		}
+		res.Id = calls[i].Id
+		responses[i] = res
+		if res.Error == nil {
+			s.storeResult(ctx, chain, calls[i], res.Result)
+		}
+	}
+	for _, i := range pending {
+		if responses[i] == nil {
+			responses[i] = NewErrorResponse(calls[i].Id, CodeInternalError, "missing response from node")
+		}
+	}
+}
+
+// call send calls to the first healthy node, failing over to the next one on network errors, bad statuses or bodies
+func (s *Service) call(ctx context.Context, chain *Chain, calls []*Request) ([]*Response, error) {
+	var payload []byte
+	var e0 error
+	if len(calls) == 1 {
+		payload, e0 = json.Marshal(calls[0])
+	} else {
+		payload, e0 = json.Marshal(calls)
+	}
+	if e0 != nil {
+		return nil, e0
+	}
+
+	lastErr := ErrNodeUnavailable
+	for _, n := range chain.orderedNodes() {
+		body, e1 := s.post(ctx, n.url, payload)
+		if e1 != nil && ctx.Err() != nil {
+			// client is gone or timed out, the node is not to blame
+			return nil, ctx.Err()
+		}
+		var responses []*Response
+		if e1 == nil {
+			responses, e1 = decodeResponses(body)
+		}
+		if e1 != nil {
+			n.unhealthyUntil.Store(time.Now().Add(s.nodeCooldown).UnixMilli())
+			lastErr = e1
+			continue
+		}
+		n.unhealthyUntil.Store(0)
+		return responses, nil
+	}
+	return nil, lastErr
+}
+
+// decodeResponses decode a single or batch json-rpc response body
+func decodeResponses(body []byte) ([]*Response, error) {
+	trimmed := bytes.TrimSpace(body)
+	if len(trimmed) > 0 && trimmed[0] == '[' {
+		var responses []*Response
+		if e0 := json.Unmarshal(trimmed, &responses); e0 != nil {
+			return nil, fmt.Errorf("bad rpc node response: %w", e0)
+		}
+		return responses, nil
+	}
+	var res Response
+	if e1 := json.Unmarshal(trimmed, &res); e1 != nil {
+		return nil, fmt.Errorf("bad rpc node response: %w", e1)
+	}
+	return []*Response{&res}, nil
+}
+
+func (s *Service) post(ctx context.Context, nodeUrl string, payload []byte) ([]byte, error) {
+	req, e0 := http.NewRequestWithContext(ctx, http.MethodPost, nodeUrl, bytes.NewReader(payload))
+	if e0 != nil {
+		return nil, e0
+	}
+	req.Header.Set("Content-Type", "application/json")
+	res, e1 := s.client.Do(req)
+	if e1 != nil {
+		return nil, e1
+	}
+	defer func() {
+		_ = res.Body.Close()
+	}()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("rpc node responded %d", res.StatusCode)
+	}
+	return io.ReadAll(io.LimitReader(res.Body, maxNodeResponseBytes))
+}
+
+// orderedNodes healthy nodes first in configured order, nodes in cooldown are kept as the last resort
+func (c *Chain) orderedNodes() []*node {
+	now := time.Now().UnixMilli()
+	healthy := make([]*node, 0, len(c.nodes))
+	var unhealthy []*node
+	for _, n := range c.nodes {
+		if n.unhealthyUntil.Load() > now {
+			unhealthy = append(unhealthy, n)
+		} else {
+			healthy = append(healthy, n)
+		}
+	}
+	return append(healthy, unhealthy...)
+}
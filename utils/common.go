@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"game-mining-server/entities"
 	"github.com/google/uuid"
+	"math"
 	"math/rand"
 	"os"
 	"strconv"
@@ -145,10 +146,13 @@ func GenReferralCode(uid int64) string {
 
 // GetUTC0Ts Get today's 0:00 time in ts in UTC locale
 func GetUTC0Ts() int64 {
-	now := time.Now()
-	utcLoc, _ := time.LoadLocation("UTC")
-	utc0Time := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, utcLoc)
-	return utc0Time.UnixMilli()
+	return GetUTC0TsAt(time.Now())
+}
+
+// GetUTC0TsAt Get 0:00 time in ts in UTC locale of the day t belongs to
+func GetUTC0TsAt(t time.Time) int64 {
+	utcTime := t.UTC()
+	return time.Date(utcTime.Year(), utcTime.Month(), utcTime.Day(), 0, 0, 0, 0, time.UTC).UnixMilli()
 }
 
 func IntMin(a, b int) int {
@@ -158,6 +162,13 @@ func IntMin(a, b int) int {
 	return b
 }
 
+func Int64Min(a, b int64) int64 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
 func CalPointForDailyCheckin(basePoint int64, continuousDays int) int64 {
 	if continuousDays <= 1 {
 		return basePoint
@@ -178,6 +189,29 @@ func CalPointForDailyCheckin(basePoint int64, continuousDays int) int64 {
 	}
 }
 
+// CalPointWithMultiplier points multiplied by multiplier, rounded to the nearest point
+func CalPointWithMultiplier(point int64, multiplier float64) int64 {
+	return int64(math.Round(float64(point) * multiplier))
+}
+
+// CalUpgradeCost points cost of an upgrade level, the first level costs baseCost and each next level costGrowth times more
+func CalUpgradeCost(baseCost int64, costGrowth float64, level int) int64 {
+	return int64(math.Round(float64(baseCost) * math.Pow(costGrowth, float64(level-1))))
+}
+
+// CalPointForMining points mined at ratePerHour within elapsedMs, capped by capacity.
+// Return mined points and the milliseconds they took, the rest of elapsed time keeps mining unless storage is full.
+func CalPointForMining(ratePerHour int64, capacity int64, elapsedMs int64) (int64, int64) {
+	if ratePerHour <= 0 || elapsedMs <= 0 {
+		return 0, 0
+	}
+	mined := ratePerHour * elapsedMs / 3600000
+	if mined >= capacity {
+		return capacity, elapsedMs
+	}
+	return mined, mined * 3600000 / ratePerHour
+}
+
 var levelList = []int64{0, 1, 5, 10, 20, 50, 100, 150, 500, 2000, 5000, 10000, 20000, 50000}
 
 func indexOfList(ele int64) int {
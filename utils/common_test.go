@@ -0,0 +1,51 @@
+package utils
+
+import "testing"
+
+func TestCalPointForMining(t *testing.T) {
+	cases := []struct {
+		name        string
+		ratePerHour int64
+		capacity    int64
+		elapsedMs   int64
+		wantMined   int64
+		wantUsedMs  int64
+	}{
+		{"whole hours", 10, 100, 2 * 3600000, 20, 2 * 3600000},
+		{"partial point keeps leftover time", 10, 100, 3600000 + 1000, 10, 3600000},
+		{"leftover not a multiple of a point", 7, 100, 1000000, 1, 514285},
+		{"less than a point", 10, 100, 1000, 0, 0},
+		{"exactly full", 10, 20, 2 * 3600000, 20, 2 * 3600000},
+		{"full storage uses all elapsed time", 10, 20, 5 * 3600000, 20, 5 * 3600000},
+		{"no rate", 0, 20, 3600000, 0, 0},
+		{"no time", 10, 20, 0, 0, 0},
+		{"clock moved back", 10, 20, -1000, 0, 0},
+	}
+	for _, c := range cases {
+		mined, usedMs := CalPointForMining(c.ratePerHour, c.capacity, c.elapsedMs)
+		if mined != c.wantMined || usedMs != c.wantUsedMs {
+			t.Errorf("%s: want %d points in %dms, got %d in %dms", c.name, c.wantMined, c.wantUsedMs, mined, usedMs)
+		}
+	}
+}
+
+func TestCalUpgradeCost(t *testing.T) {
+	cases := []struct {
+		baseCost   int64
+		costGrowth float64
+		level      int
+		want       int64
+	}{
+		{100, 1.6, 1, 100},
+		{100, 1.6, 2, 160},
+		{100, 1.6, 3, 256},
+		{100, 1.6, 4, 410}, // 409.6 rounded
+		{500, 2, 5, 8000},
+		{100, 1, 10, 100},
+	}
+	for _, c := range cases {
+		if got := CalUpgradeCost(c.baseCost, c.costGrowth, c.level); got != c.want {
+			t.Errorf("CalUpgradeCost(%d, %v, %d): want %d, got %d", c.baseCost, c.costGrowth, c.level, c.want, got)
+		}
+	}
+}